@@ -2,22 +2,77 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"trust_walet/internal/ethereum"
+	"trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/domain"
 	"trust_walet/internal/ethereum/rpc"
 	"trust_walet/internal/ethereum/storage"
 
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	if err := run(); err != nil {
+		fmt.Printf("Exiting on error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run returns instead of exiting, so deferred closing of storages and connections is not skipped.
+func run() error {
+	confirmations := flag.Int("confirmations", 12, "number of blocks built on top before transaction is confirmed")
+	storageType := flag.String("storage", "memory", "storage backend: memory or file")
+	dataDir := flag.String("data-dir", "data", "directory for file storage")
+	startBlockValue := flag.String("start-block", "latest", "first block when nothing was processed yet: latest, genesis, number or latest-N")
+	rpcURLs := flag.String("rpc-url", rpc.EthereumUrl, "comma separated http node urls, calls are routed to the healthiest one")
+	wsURL := flag.String("ws-url", "", "websocket node url, new heads are pushed instead of polling when set")
+	pollInterval := flag.Duration("poll-interval", 5*time.Second, "block polling interval, used as fallback with websocket")
+	concurrency := flag.Int("concurrency", domain.DefaultConcurrency, "maximum number of parallel block requests")
+	retryAttempts := flag.Int("retry-attempts", rpc.DefaultRetryMaxAttempts, "maximum number of tries of a failed rpc call")
+	quorum := flag.Int("quorum", 0, "number of -rpc-url nodes which must return the same block, every node is asked when set")
+	retryBackoff := flag.Duration("retry-backoff", rpc.DefaultRetryMinBackoff, "delay before the first retry of a failed rpc call, doubled on every next one")
+	receipts := flag.Bool("receipts", true, "fetch receipts of found transactions for status, gas used and fee")
+	dropFailed := flag.Bool("drop-failed", false, "skip reverted transactions, requires -receipts")
+	tokenTransfers := flag.Bool("token-transfers", true, "track ERC-20 transfers from and to the address")
+	nftTransfers := flag.Bool("nft-transfers", true, "track ERC-721 and ERC-1155 transfers from and to the address")
+	internalTransfers := flag.Bool("internal-transfers", false, "track ETH sent by contract calls, node must provide debug or trace API")
+	rateLimit := flag.Float64("rate-limit", 0, "maximum requests per second to every node, 0 disables limiting")
+	rateBurst := flag.Int("rate-burst", 1, "number of requests which may be sent to a node at once")
+	nodeRateLimits := flag.String("node-rate-limit", "", "comma separated url=requests per second, overrides -rate-limit for the node")
+	addressBloom := flag.Bool("address-bloom", false, "pre-check subscribed addresses with bloom filter, useful for large watch lists")
+	eventBuffer := flag.Int("event-buffer", domain.DefaultEventBuffer, "number of transaction events kept for the logger")
+	eventBackpressure := flag.String("event-backpressure", string(data.BackpressureBlock), "policy when event buffer is full: block, drop-oldest or error")
+	backfillFrom := flag.Int("backfill-from", -1, "scan history of the address from this block in background, negative disables backfill")
+	flag.Parse()
+
+	startBlock, err := domain.ParseStartBlock(*startBlockValue)
+	if err != nil {
+		return fmt.Errorf("error parsing start block: %w", err)
+	}
+
+	urls := splitURLs(*rpcURLs)
+	if *quorum > len(urls) {
+		return fmt.Errorf("error parsing quorum: %d of %d -rpc-url nodes can not agree", *quorum, len(urls))
+	}
+
+	rateLimitOverrides, err := parseRateLimits(*nodeRateLimits)
+	if err != nil {
+		return fmt.Errorf("error parsing node rate limits: %w", err)
+	}
+
 	logrus.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
@@ -28,13 +83,102 @@ func main() {
 
 	address := "0xe7d36d7f5832349f7a9f04c898a1e47992f02bd5"
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
-	parser := createParser(rpc.EthereumUrl)
+	storages, err := createStorages(*storageType, *dataDir, storage.AddressConfig{
+		BloomFilter: *addressBloom,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating storage: %w", err)
+	}
+	defer storages.Close()
+
+	retryConfig := rpc.RetryConfig{
+		MaxAttempts: *retryAttempts,
+		MinBackoff:  *retryBackoff,
+	}
+
+	limiters := &rateLimiters{
+		config: rpc.RateLimitConfig{
+			RequestsPerSecond: *rateLimit,
+			Burst:             *rateBurst,
+		},
+		overrides: rateLimitOverrides,
+	}
+
+	var (
+		client rpcClient
+		heads  chan rpc.Header
+		ws     *rpc.WebSocket
+	)
+	if *wsURL != "" {
+		ws = rpc.NewWebSocket(websocket.DefaultDialer, *wsURL)
+		defer ws.Close()
+
+		// with quorum websocket only pushes new heads, blocks are requested from every node
+		heads = make(chan rpc.Header)
+		watched := make(chan struct{})
+		go func() {
+			defer close(watched)
+			ws.WatchNewHeads(ctx, heads)
+		}()
+
+		// connection is closed only after watcher has unsubscribed
+		defer func() {
+			cancel()
+			<-watched
+		}()
+	}
+
+	switch {
+	case *quorum > 0:
+		quorumClient, err := createQuorum(urls, *quorum, limiters, retryConfig)
+		if err != nil {
+			return err
+		}
+		client = quorumClient
+	case ws != nil:
+		client = rpc.NewRetry(limiters.create(ws, *wsURL), retryConfig)
+	default:
+		pool := createPool(urls, limiters)
+		go pool.WatchHeads(ctx)
+
+		client = rpc.NewRetry(pool, retryConfig)
+	}
+
+	parser := createParser(client, storages, domain.TransactionServiceConfig{
+		Confirmations:     *confirmations,
+		Receipts:          *receipts,
+		DropFailed:        *dropFailed,
+		TokenTransfers:    *tokenTransfers,
+		NFTTransfers:      *nftTransfers,
+		InternalTransfers: *internalTransfers,
+	}, startBlock, *concurrency)
+
+	events, err := parser.Events(ctx, data.EventOptions{
+		Address:      address,
+		Buffer:       *eventBuffer,
+		Backpressure: data.Backpressure(*eventBackpressure),
+	})
+	if err != nil {
+		return err
+	}
+
+	if *backfillFrom >= 0 {
+		// current block is known only after the first processing, history is scanned up to it
+		if err := parser.MonitorTransactions(ctx); err != nil {
+			return fmt.Errorf("error processing blocks: %w", err)
+		}
 
-	parser.Subscribe(address)
+		if _, err := parser.SubscribeFrom(ctx, data.Subscription{Address: address}, *backfillFrom); err != nil {
+			return err
+		}
+	} else if _, err := parser.Subscribe(address); err != nil {
+		return err
+	}
 
 	fmt.Printf("Collecting transactions for %s address...\n", address)
 
@@ -44,23 +188,34 @@ func main() {
 		cancel()
 	}()
 
-	go func(ctx context.Context) {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
+	go func() {
+		for event := range events.Events() {
+			t := event.Transaction
+			if event.Kind == data.EventKindRetracted {
+				fmt.Printf("Retracted %s transfer: block=%d hash=%s\n", t.Kind, t.BlockNumber, t.Hash)
+				continue
+			}
 
-		for {
-			select {
-			case <-ctx.Done():
-				fmt.Println("Stopping transaction logger...")
-				return
-			case <-ticker.C:
-				tx := parser.GetTransactions(address)
-				for _, t := range tx {
-					fmt.Printf("New transaction: hash=%s value=%s from=%s to=%s\n", t.Hash, t.Value, t.From, t.To)
-				}
+			switch t.Kind {
+			case data.TransferKindNative:
+				fmt.Printf("New transaction: block=%d hash=%s value=%s from=%s to=%s nonce=%d status=%s fee=%s\n", t.BlockNumber, t.Hash, t.Value, t.From, t.To, t.Nonce, t.Status, t.Fee)
+			case data.TransferKindDeployment:
+				fmt.Printf("New deployment: block=%d hash=%s contract=%s from=%s nonce=%d status=%s fee=%s\n", t.BlockNumber, t.Hash, t.ContractAddress, t.From, t.Nonce, t.Status, t.Fee)
+			case data.TransferKindInternal:
+				fmt.Printf("New %s transfer: block=%d hash=%s value=%s from=%s to=%s status=%s\n", t.Kind, t.BlockNumber, t.Hash, t.Value, t.From, t.To, t.Status)
+			case data.TransferKindERC20:
+				fmt.Printf("New %s transfer: block=%d hash=%s token=%s amount=%s from=%s to=%s status=%s\n", t.Kind, t.BlockNumber, t.Hash, t.TokenContract, t.Value, t.From, t.To, t.Status)
+			default:
+				fmt.Printf("New %s transfer: block=%d hash=%s token=%s ids=%v amounts=%v from=%s to=%s status=%s\n", t.Kind, t.BlockNumber, t.Hash, t.TokenContract, t.TokenIDs, t.TokenAmounts, t.From, t.To, t.Status)
 			}
 		}
-	}(ctx)
+
+		if err := events.Err(); err != nil {
+			fmt.Printf("Transaction logger stopped: %v\n", err)
+			return
+		}
+		fmt.Println("Stopping transaction logger...")
+	}()
 
 	go func(ctx context.Context) {
 		ticker := time.NewTicker(10 * time.Second)
@@ -73,41 +228,218 @@ func main() {
 				return
 			case <-ticker.C:
 				fmt.Printf("Current block: 0x%x\n", parser.GetCurrentBlock())
+				if progress, ok := parser.BackfillProgress(address); ok && progress.State == data.BackfillStateRunning {
+					fmt.Printf("Backfill: block=%d of %d-%d (%.1f%%)\n", progress.CurrentBlock, progress.FromBlock, progress.ToBlock, progress.Percent())
+				}
+				limiters.printStats()
 			}
 		}
 	}(ctx)
 
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Println("Stopping transaction monitor...")
-			return
-		default:
-			if err := parser.MonitorTransactions(ctx); err != nil {
-				fmt.Printf("Error while monitoring new transactions: %v\n", err)
-				cancel()
-				return
-			}
+	parser.Run(ctx, heads, *pollInterval)
+
+	fmt.Println("Stopping transaction monitor...")
+
+	return nil
+}
 
-			time.Sleep(5 * time.Second)
+func splitURLs(value string) []string {
+	var urls []string
+	for _, url := range strings.Split(value, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
 		}
 	}
+
+	return urls
 }
 
-func createParser(url string) *ethereum.Parser {
-	client := rpc.NewHttp(&http.Client{}, url)
+// parseRateLimits parses comma separated url=requests per second pairs, the last "=" splits
+// them, as url may have it in query.
+func parseRateLimits(value string) (map[string]float64, error) {
+	limits := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
 
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid node rate limit %q, url=requests per second expected", pair)
+		}
+
+		limit, err := strconv.ParseFloat(pair[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node rate limit %q: %w", pair, err)
+		}
+		limits[strings.TrimSpace(pair[:i])] = limit
+	}
+
+	return limits, nil
+}
+
+// rateLimiters creates rate limiter of every node and keeps them for status log.
+type rateLimiters struct {
+	config    rpc.RateLimitConfig
+	overrides map[string]float64
+	urls      []string
+	limiters  []*rpc.RateLimiter
+}
+
+func (r *rateLimiters) create(caller rpc.Caller, url string) *rpc.RateLimiter {
+	config := r.config
+	if limit, ok := r.overrides[url]; ok {
+		config.RequestsPerSecond = limit
+	}
+
+	limiter := rpc.NewRateLimiter(caller, config)
+	if config.RequestsPerSecond > 0 {
+		r.urls = append(r.urls, url)
+		r.limiters = append(r.limiters, limiter)
+	}
+
+	return limiter
+}
+
+func (r *rateLimiters) printStats() {
+	for i, limiter := range r.limiters {
+		stats := limiter.Stats()
+		fmt.Printf("Rate limit: url=%s requests=%d waited=%d wait=%s\n", r.urls[i], stats.Requests, stats.Waited, stats.WaitTime)
+	}
+}
+
+// createPool limits request rate of every node separately.
+func createPool(urls []string, limiters *rateLimiters) *rpc.Pool {
+	endpoints := make([]rpc.PoolEndpoint, 0, len(urls))
+	for _, url := range urls {
+		endpoints = append(endpoints, rpc.PoolEndpoint{
+			URL:    url,
+			Caller: limiters.create(rpc.NewHttp(&http.Client{}, url), url),
+		})
+	}
+
+	return rpc.NewPool(endpoints, rpc.PoolConfig{})
+}
+
+func createQuorum(urls []string, threshold int, limiters *rateLimiters, retryConfig rpc.RetryConfig) (*rpc.Quorum, error) {
+	providers := make([]rpc.QuorumProvider, 0, len(urls))
+	for _, url := range urls {
+		limiter := limiters.create(rpc.NewHttp(&http.Client{}, url), url)
+		providers = append(providers, rpc.QuorumProvider{
+			URL:    url,
+			Client: rpc.NewRetry(limiter, retryConfig),
+		})
+	}
+
+	return rpc.NewQuorum(providers, rpc.QuorumConfig{
+		Threshold: threshold,
+	})
+}
+
+type rpcClient interface {
+	domain.BlockRpcClient
+	domain.TransactionRpcClient
+}
+
+// eventServiceAdapter adapts domain.EventService to ethereum.EventService, which does not depend on domain types.
+type eventServiceAdapter struct {
+	*domain.EventService
+}
+
+func (e eventServiceAdapter) Subscribe(ctx context.Context, options data.EventOptions) (ethereum.EventSubscription, error) {
+	subscription, err := e.EventService.Subscribe(ctx, options)
+	if err != nil {
+		// nil subscription must not become non-nil interface
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+type storages struct {
+	address     domain.AddressStorage
+	block       domain.BlockStorage
+	transaction domain.TransactionStorage
+	closers     []io.Closer
+}
+
+func (s *storages) Close() {
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil {
+			fmt.Printf("Error while closing storage: %v\n", err)
+		}
+	}
+}
+
+func createStorages(storageType, dataDir string, addressConfig storage.AddressConfig) (*storages, error) {
+	switch storageType {
+	case "memory":
+		return &storages{
+			address:     storage.NewAddressInMemoryWithConfig(addressConfig),
+			block:       storage.NewBlockInMemory(),
+			transaction: storage.NewTransactionInMemory(),
+		}, nil
+	case "file":
+		if err := os.MkdirAll(dataDir, 0o755); err != nil {
+			return nil, fmt.Errorf("error creating data directory: %w", err)
+		}
+
+		result := &storages{}
+
+		address, err := storage.NewAddressFileWithConfig(filepath.Join(dataDir, "addresses.jsonl"), addressConfig)
+		if err != nil {
+			return nil, err
+		}
+		result.address = address
+		result.closers = append(result.closers, address)
+
+		block, err := storage.NewBlockFile(filepath.Join(dataDir, "blocks.jsonl"))
+		if err != nil {
+			result.Close()
+			return nil, err
+		}
+		result.block = block
+		result.closers = append(result.closers, block)
+
+		transaction, err := storage.NewTransactionFile(filepath.Join(dataDir, "transactions.jsonl"))
+		if err != nil {
+			result.Close()
+			return nil, err
+		}
+		result.transaction = transaction
+		result.closers = append(result.closers, transaction)
+
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unknown storage type %q", storageType)
+}
+
+func createParser(client rpcClient, storages *storages, transactionConfig domain.TransactionServiceConfig, startBlock domain.StartBlock, concurrency int) *ethereum.Parser {
 	addressService := domain.NewAddressService(
-		storage.NewAddressInMemory(),
+		storages.address,
 	)
+	eventService := domain.NewEventService()
 	transactionService := domain.NewTransactionService(
 		client,
 		addressService,
-		storage.NewTransactionInMemory(),
+		storages.transaction,
+		eventService,
+		transactionConfig,
 	)
 	blockService := domain.NewBlockService(
 		client,
-		storage.NewBlockInMemory(),
+		storages.block,
+		transactionService,
+		domain.BlockServiceConfig{
+			ReorgWindow: max(domain.DefaultReorgWindow, transactionConfig.Confirmations+1),
+			StartBlock:  startBlock,
+			Concurrency: concurrency,
+		},
+	)
+
+	backfillService := domain.NewBackfillService(
+		blockService,
 		transactionService,
 	)
 
@@ -115,5 +447,7 @@ func createParser(url string) *ethereum.Parser {
 		addressService,
 		blockService,
 		transactionService,
+		backfillService,
+		eventServiceAdapter{eventService},
 	)
 }
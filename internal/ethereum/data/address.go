@@ -0,0 +1,85 @@
+package data
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Address is account or contract address normalised to lowercase hex with 0x prefix,
+// as node returns it.
+type Address string
+
+const addressLength = 40
+
+var ErrInvalidAddress = errors.New("invalid address")
+
+// ParseAddress validates address and normalises its case. Mixed-case address must have
+// valid EIP-55 checksum, all lowercase or all uppercase address has no checksum.
+func ParseAddress(value string) (Address, error) {
+	trimmed := strings.TrimSpace(value)
+
+	digits, ok := strings.CutPrefix(trimmed, "0x")
+	if !ok {
+		digits, ok = strings.CutPrefix(trimmed, "0X")
+	}
+	if !ok {
+		return "", fmt.Errorf("%w %q: missing 0x prefix", ErrInvalidAddress, value)
+	}
+
+	if len(digits) != addressLength {
+		return "", fmt.Errorf("%w %q: expected %d hex digits, got %d", ErrInvalidAddress, value, addressLength, len(digits))
+	}
+
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", fmt.Errorf("%w %q: not a hex string", ErrInvalidAddress, value)
+	}
+
+	address := Address("0x" + strings.ToLower(digits))
+
+	lower, upper := strings.ToLower(digits), strings.ToUpper(digits)
+	if digits != lower && digits != upper {
+		if checksum := address.Checksum(); "0x"+digits != checksum {
+			return "", fmt.Errorf("%w %q: invalid EIP-55 checksum, expected %s", ErrInvalidAddress, value, checksum)
+		}
+	}
+
+	return address, nil
+}
+
+// NormalizeAddress returns lookup key of address without validation, invalid address
+// is never stored, so it just does not match anything.
+func NormalizeAddress(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func (a Address) String() string {
+	return string(a)
+}
+
+// Checksum returns address in EIP-55 mixed-case form: hex letter is uppercase when
+// the matching nibble of keccak256 of lowercase address is 8 or more.
+func (a Address) Checksum() string {
+	digits := strings.TrimPrefix(string(a), "0x")
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(digits))
+	sum := hash.Sum(nil)
+
+	checksum := []byte(digits)
+	for i, c := range checksum {
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0x0f
+		}
+
+		if c >= 'a' && c <= 'f' && nibble >= 8 {
+			checksum[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(checksum)
+}
@@ -0,0 +1,7 @@
+package data
+
+type Block struct {
+	Number     int
+	Hash       string
+	ParentHash string
+}
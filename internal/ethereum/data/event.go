@@ -0,0 +1,43 @@
+package data
+
+type (
+	// Backpressure tells what happens when buffer of a slow event subscriber is full.
+	Backpressure string
+
+	// EventKind tells what happened to the transaction of event.
+	EventKind string
+
+	// TransactionEvent is sent when transaction of subscribed address is saved, it may be
+	// not confirmed yet, and when saved transaction is retracted by chain reorganization.
+	TransactionEvent struct {
+		Kind        EventKind
+		Address     string
+		Transaction Transaction
+	}
+
+	EventOptions struct {
+		// Address selects events of a single address, empty means every subscribed address.
+		Address string
+		// Buffer is the number of events kept for subscriber which does not keep up.
+		Buffer int
+		// Backpressure is the policy applied when buffer is full.
+		Backpressure Backpressure
+	}
+)
+
+const (
+	// EventKindSaved is sent when transaction is found in a processed block.
+	EventKindSaved EventKind = "saved"
+	// EventKindRetracted is sent when block of saved transaction is no longer canonical,
+	// the transaction may be saved again from the new block.
+	EventKindRetracted EventKind = "retracted"
+)
+
+const (
+	// BackpressureBlock makes block processing wait until subscriber takes the event.
+	BackpressureBlock Backpressure = "block"
+	// BackpressureDropOldest drops the oldest buffered event to make room for the new one.
+	BackpressureDropOldest Backpressure = "drop-oldest"
+	// BackpressureError closes subscription with an error, so subscriber can resync via QueryTransactions.
+	BackpressureError Backpressure = "error"
+)
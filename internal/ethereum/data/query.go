@@ -0,0 +1,69 @@
+package data
+
+import "slices"
+
+type (
+	Direction string
+
+	// TransactionFilter selects stored transactions of address in chain order.
+	TransactionFilter struct {
+		FromBlock int
+		ToBlock   int
+		Direction Direction
+		// After skips transactions up to and including the given position.
+		After *TransactionPosition
+		// Limit is the maximum number of returned transactions, zero means no limit.
+		Limit int
+		// Kinds selects transfers of the given kinds, empty means every kind.
+		Kinds []TransferKind
+	}
+
+	// TransactionQuery is a page request for confirmed transactions of address.
+	TransactionQuery struct {
+		FromBlock int
+		// ToBlock is the last block of range, zero means the last confirmed block.
+		ToBlock   int
+		Direction Direction
+		// Cursor is NextCursor of the previous page, empty for the first page.
+		Cursor string
+		Limit  int
+		// Kinds selects transfers of the given kinds, empty means every kind.
+		Kinds []TransferKind
+	}
+
+	TransactionPage struct {
+		Transactions []Transaction
+		// NextCursor is empty when there are no more transactions.
+		NextCursor string
+	}
+)
+
+const (
+	DirectionAny      Direction = ""
+	DirectionIncoming Direction = "incoming"
+	DirectionOutgoing Direction = "outgoing"
+)
+
+// Match reports whether transaction of address satisfies the filter.
+func (f *TransactionFilter) Match(address string, tx *Transaction) bool {
+	if tx.BlockNumber < f.FromBlock || tx.BlockNumber > f.ToBlock {
+		return false
+	}
+
+	if f.After != nil && tx.Position().Compare(*f.After) <= 0 {
+		return false
+	}
+
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, tx.Kind) {
+		return false
+	}
+
+	switch f.Direction {
+	case DirectionIncoming:
+		return tx.To == address
+	case DirectionOutgoing:
+		return tx.From == address
+	}
+
+	return true
+}
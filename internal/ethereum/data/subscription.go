@@ -0,0 +1,39 @@
+package data
+
+// Subscription is tracked address with its metadata. CreatedAtBlock is the head block when
+// address was subscribed, Owner is id of tenant which subscribed it.
+type Subscription struct {
+	Address        string
+	Label          string
+	Owner          string
+	CreatedAtBlock int
+}
+
+type BackfillState string
+
+const (
+	BackfillStateRunning  BackfillState = "running"
+	BackfillStateDone     BackfillState = "done"
+	BackfillStateFailed   BackfillState = "failed"
+	BackfillStateCanceled BackfillState = "canceled"
+)
+
+// BackfillProgress reports scan of history of address, CurrentBlock is the last scanned block.
+type BackfillProgress struct {
+	Address      string
+	FromBlock    int
+	ToBlock      int
+	CurrentBlock int
+	State        BackfillState
+	Error        string
+}
+
+// Percent returns share of scanned blocks from 0 to 100.
+func (p BackfillProgress) Percent() float64 {
+	total := p.ToBlock - p.FromBlock + 1
+	if total <= 0 || p.State == BackfillStateDone {
+		return 100
+	}
+
+	return float64(p.CurrentBlock-p.FromBlock+1) * 100 / float64(total)
+}
@@ -1,8 +1,140 @@
 package data
 
-type Transaction struct {
-	Hash  string
-	From  string
-	To    string
-	Value string
+import "fmt"
+
+type (
+	TransactionStatus string
+
+	// TransferKind tells what was transferred, native transfer is the transaction itself.
+	TransferKind string
+)
+
+const (
+	// TransactionStatusUnknown is kept when receipt was not fetched or block is before Byzantium fork.
+	TransactionStatusUnknown TransactionStatus = ""
+	TransactionStatusSuccess TransactionStatus = "success"
+	TransactionStatusFailed  TransactionStatus = "failed"
+
+	TransferKindNative     TransferKind = ""
+	TransferKindDeployment TransferKind = "deployment"
+	TransferKindInternal   TransferKind = "internal"
+	TransferKindERC20      TransferKind = "erc20"
+	TransferKindERC721     TransferKind = "erc721"
+	TransferKindERC1155    TransferKind = "erc1155"
+)
+
+type (
+	// Transaction amounts in wei (Value, GasPrice, fee caps and Fee) are hex encoded as node returns them,
+	// they may not fit into int64. Timestamp is block time in unix seconds.
+	// Status, GasUsed, EffectiveGasPrice, ContractAddress and Fee come from transaction receipt.
+	//
+	// Token transfer keeps fields of its transaction, but From, To and Value are sender, receiver
+	// and amount of the token, EventIndex is the log index in block. Fee is set only for native
+	// transfer, as it is paid once per transaction.
+	//
+	// NFT transfer has no Value, TokenIDs lists transferred tokens and TokenAmounts their quantities
+	// in the same order. ERC-721 transfer moves a single token, so its quantity is always 1.
+	//
+	// Deployment is transaction without receiver which creates contract, ContractAddress is the created
+	// contract and To stays empty.
+	//
+	// Internal transfer is ETH sent by contract call inside transaction, EventIndex is the number
+	// of the call in call tree of transaction, the transaction itself is call 0.
+	Transaction struct {
+		Kind                 TransferKind
+		BlockNumber          int
+		BlockHash            string
+		Timestamp            int64
+		TransactionIndex     int
+		Hash                 string
+		From                 string
+		To                   string
+		Value                string
+		Nonce                uint64
+		Gas                  uint64
+		GasPrice             string
+		MaxFeePerGas         string
+		MaxPriorityFeePerGas string
+		Type                 int
+		ChainID              int64
+		Input                string
+		Status               TransactionStatus
+		GasUsed              uint64
+		EffectiveGasPrice    string
+		ContractAddress      string
+		Fee                  string
+		TokenContract        string
+		TokenIDs             []string
+		TokenAmounts         []string
+		EventIndex           int
+	}
+
+	// TransactionPosition points to transfer in chain by block number, index in block
+	// and, for transfers made inside transaction, by kind and event index.
+	TransactionPosition struct {
+		BlockNumber      int
+		TransactionIndex int
+		Kind             TransferKind
+		EventIndex       int
+	}
+)
+
+// ID identifies transfer, it is transaction hash for the transaction itself.
+func (t *Transaction) ID() string {
+	if t.Kind.IsTransaction() {
+		return t.Hash
+	}
+
+	return fmt.Sprintf("%s:%s:%d", t.Hash, t.Kind, t.EventIndex)
+}
+
+func (t *Transaction) Position() TransactionPosition {
+	return TransactionPosition{
+		BlockNumber:      t.BlockNumber,
+		TransactionIndex: t.TransactionIndex,
+		Kind:             t.Kind,
+		EventIndex:       t.EventIndex,
+	}
+}
+
+// Compare returns -1, 0 or +1 depending on whether p is before, same or after other in chain.
+func (p TransactionPosition) Compare(other TransactionPosition) int {
+	switch {
+	case p.BlockNumber < other.BlockNumber:
+		return -1
+	case p.BlockNumber > other.BlockNumber:
+		return 1
+	case p.TransactionIndex < other.TransactionIndex:
+		return -1
+	case p.TransactionIndex > other.TransactionIndex:
+		return 1
+	case p.Kind.rank() < other.Kind.rank():
+		return -1
+	case p.Kind.rank() > other.Kind.rank():
+		return 1
+	case p.EventIndex < other.EventIndex:
+		return -1
+	case p.EventIndex > other.EventIndex:
+		return 1
+	}
+
+	return 0
+}
+
+// IsTransaction tells whether transfer is the transaction itself, not a transfer made inside it.
+func (k TransferKind) IsTransaction() bool {
+	return k == TransferKindNative || k == TransferKindDeployment
+}
+
+// rank orders transfers of one transaction: the transaction goes first, then internal
+// transfers by call index and transfers from logs, which share log index.
+func (k TransferKind) rank() int {
+	switch {
+	case k.IsTransaction():
+		return 0
+	case k == TransferKindInternal:
+		return 1
+	}
+
+	return 2
 }
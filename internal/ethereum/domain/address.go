@@ -1,11 +1,22 @@
 package domain
 
-import "github.com/sirupsen/logrus"
+import (
+	"fmt"
+
+	"trust_walet/internal/ethereum/data"
+
+	"github.com/sirupsen/logrus"
+)
 
 type (
 	AddressStorage interface {
 		Exists(address string) bool
-		Add(address string)
+		// Add returns false when address is already subscribed.
+		Add(subscription data.Subscription) (bool, error)
+		AddMany(subscriptions []data.Subscription) ([]data.Subscription, error)
+		Remove(address string) (bool, error)
+		RemoveMany(addresses []string) ([]string, error)
+		List() []data.Subscription
 	}
 
 	AddressService struct {
@@ -19,26 +30,154 @@ func NewAddressService(addressStorage AddressStorage) *AddressService {
 	}
 }
 
-func (a *AddressService) AddUnique(address string) bool {
-	if a.storage.Exists(address) {
+// AddUnique validates subscription address and adds it in normalised form, it returns false when address is already added.
+func (a *AddressService) AddUnique(subscription data.Subscription) (bool, error) {
+	normalized, err := data.ParseAddress(subscription.Address)
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"address": address,
+			"address": subscription.Address,
+		}).WithError(err).Warn("address is not valid")
+
+		return false, fmt.Errorf("error subscribing address: %w", err)
+	}
+	subscription.Address = normalized.String()
+
+	// storage decides whether address is new, so concurrent subscribes add it once
+	added, err := a.storage.Add(subscription)
+	if err != nil {
+		return false, fmt.Errorf("error subscribing address: %w", err)
+	}
+	if !added {
+		logrus.WithFields(logrus.Fields{
+			"address": subscription.Address,
 		}).Warn("address is not unique")
 
-		return false
+		return false, nil
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"address": address,
+		"address":      subscription.Address,
+		"label":        subscription.Label,
+		"owner":        subscription.Owner,
+		"block_number": subscription.CreatedAtBlock,
 	}).Info("address is added to subscribe list")
 
-	a.storage.Add(address)
+	return true, nil
+}
+
+// AddUniqueMany validates every subscription address and adds them at once, nothing is added
+// when any address is invalid. It returns subscriptions which were not added yet.
+func (a *AddressService) AddUniqueMany(subscriptions []data.Subscription) ([]data.Subscription, error) {
+	normalized := make([]data.Subscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		address, err := data.ParseAddress(subscription.Address)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"address": subscription.Address,
+			}).WithError(err).Warn("address is not valid")
+
+			return nil, fmt.Errorf("error subscribing addresses: %w", err)
+		}
+		subscription.Address = address.String()
+
+		normalized = append(normalized, subscription)
+	}
+
+	added, err := a.storage.AddMany(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("error subscribing addresses: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"addresses": len(subscriptions),
+		"added":     len(added),
+	}).Info("addresses are added to subscribe list")
+
+	return added, nil
+}
+
+// Remove validates address and removes it from subscribe list, it returns false when address is not subscribed.
+func (a *AddressService) Remove(address string) (bool, error) {
+	normalized, err := data.ParseAddress(address)
+	if err != nil {
+		return false, fmt.Errorf("error unsubscribing address: %w", err)
+	}
+
+	removed, err := a.storage.Remove(normalized.String())
+	if err != nil {
+		return false, fmt.Errorf("error unsubscribing address: %w", err)
+	}
+	if !removed {
+		logrus.WithFields(logrus.Fields{
+			"address": normalized,
+		}).Warn("address is not in subscribe list")
+
+		return false, nil
+	}
 
-	return false
+	logrus.WithFields(logrus.Fields{
+		"address": normalized,
+	}).Info("address is removed from subscribe list")
+
+	return true, nil
 }
 
+// RemoveMany validates every address and removes them at once, nothing is removed when any
+// address is invalid. It returns addresses which were subscribed.
+func (a *AddressService) RemoveMany(addresses []string) ([]string, error) {
+	normalized := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		parsed, err := data.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("error unsubscribing addresses: %w", err)
+		}
+
+		normalized = append(normalized, parsed.String())
+	}
+
+	removed, err := a.storage.RemoveMany(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("error unsubscribing addresses: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"addresses": len(addresses),
+		"removed":   len(removed),
+	}).Info("addresses are removed from subscribe list")
+
+	return removed, nil
+}
+
+// List returns subscriptions of owner in the order their addresses were subscribed, empty owner means every subscription.
+func (a *AddressService) List(owner string) []data.Subscription {
+	subscriptions := a.storage.List()
+	if owner == "" {
+		return subscriptions
+	}
+
+	var owned []data.Subscription
+	for _, subscription := range subscriptions {
+		if subscription.Owner == owner {
+			owned = append(owned, subscription)
+		}
+	}
+
+	return owned
+}
+
+// IsSubscribed compares address case insensitively. It never matches empty address,
+// which stands for missing receiver of contract creation.
 func (a *AddressService) IsSubscribed(address string) bool {
-	subscribed := a.storage.Exists(address)
+	if address == "" {
+		return false
+	}
+
+	subscribed := a.storage.Exists(data.NormalizeAddress(address))
+
+	// it is called for every transfer of every block, fields are not built when not logged
+	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
+		return subscribed
+	}
 
 	message := "address is in subscribe list"
 	if !subscribed {
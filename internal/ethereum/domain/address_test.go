@@ -1,7 +1,9 @@
 package domain_test
 
 import (
+	"slices"
 	"testing"
+	"trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/domain"
 
 	"github.com/stretchr/testify/assert"
@@ -12,16 +14,28 @@ import (
 
 func TestAddressServiceAddUnique(t *testing.T) {
 	testCases := map[string]struct {
-		exist           bool
-		expectedAddCall int
+		address       string
+		exist         bool
+		expectedAdded bool
 	}{
 		"addr unique": {
-			exist:           false,
-			expectedAddCall: 1,
+			address:       "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			exist:         false,
+			expectedAdded: true,
 		},
 		"addr not unique": {
-			exist:           true,
-			expectedAddCall: 0,
+			address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			exist:   true,
+		},
+		"addr with checksum": {
+			address:       "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			exist:         false,
+			expectedAdded: true,
+		},
+		"addr uppercase": {
+			address:       "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+			exist:         false,
+			expectedAdded: true,
 		},
 	}
 
@@ -35,14 +49,47 @@ func TestAddressServiceAddUnique(t *testing.T) {
 			service := domain.NewAddressService(mockStorage)
 
 			// assert
-			mockStorage.EXPECT().Exists(gomock.Eq("addr")).
-				Return(tc.exist).
-				Times(1)
+			mockStorage.EXPECT().Add(gomock.Eq(data.Subscription{Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"})).
+				Return(!tc.exist, nil)
+
+			// act
+			added, err := service.AddUnique(data.Subscription{Address: tc.address})
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAdded, added)
+		})
+	}
+}
+
+func TestAddressServiceAddUniqueInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"empty":            "",
+		"no prefix":        "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"short":            "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea",
+		"not hex":          "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz",
+		"invalid checksum": "0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	}
+
+	for name, address := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-			mockStorage.EXPECT().Add(gomock.Eq("addr")).Times(tc.expectedAddCall)
+			// arrange
+			mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+			service := domain.NewAddressService(mockStorage)
+
+			// assert
+			mockStorage.EXPECT().Exists(gomock.Any()).Times(0)
+			mockStorage.EXPECT().Add(gomock.Any()).Times(0)
 
 			// act
-			service.AddUnique("addr")
+			added, err := service.AddUnique(data.Subscription{Address: address})
+
+			// assert
+			assert.ErrorIs(t, err, data.ErrInvalidAddress)
+			assert.False(t, added)
 		})
 	}
 }
@@ -56,8 +103,166 @@ func TestAddressServiceIsSubscribed(t *testing.T) {
 	service := domain.NewAddressService(mockStorage)
 
 	// assert
-	mockStorage.EXPECT().Exists(gomock.Eq("addr")).Return(true)
+	mockStorage.EXPECT().Exists(gomock.Eq("0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359")).Return(true)
+
+	// act
+	assert.True(t, service.IsSubscribed("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"))
+}
+
+func TestAddressServiceEmptyAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	// assert
+	mockStorage.EXPECT().Exists(gomock.Any()).Times(0)
+	mockStorage.EXPECT().Add(gomock.Any()).Times(0)
+
+	// act
+	added, err := service.AddUnique(data.Subscription{})
+	assert.Error(t, err)
+	assert.False(t, added)
+	assert.False(t, service.IsSubscribed(""))
+}
+
+func TestAddressServiceRemove(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	// assert
+	mockStorage.EXPECT().Remove(gomock.Eq("0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359")).Return(true, nil)
+
+	// act
+	removed, err := service.Remove("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359")
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, removed)
+}
+
+func TestAddressServiceRemoveInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	// assert
+	mockStorage.EXPECT().Remove(gomock.Any()).Times(0)
 
 	// act
-	assert.True(t, service.IsSubscribed("addr"))
+	removed, err := service.Remove("addr")
+
+	// assert
+	assert.ErrorIs(t, err, data.ErrInvalidAddress)
+	assert.False(t, removed)
+}
+
+func TestAddressServiceListByOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	subscriptions := []data.Subscription{
+		{Address: "addr1", Owner: "tenant1"},
+		{Address: "addr2", Owner: "tenant2"},
+	}
+
+	// assert
+	mockStorage.EXPECT().List().Return(slices.Clone(subscriptions)).Times(2)
+
+	// act
+	all := service.List("")
+	owned := service.List("tenant2")
+
+	// assert
+	assert.Equal(t, subscriptions, all)
+	assert.Equal(t, subscriptions[1:], owned)
+}
+
+func TestAddressServiceAddUniqueMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	// assert
+	mockStorage.EXPECT().AddMany(gomock.Eq([]data.Subscription{
+		{Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Owner: "tenant"},
+		{Address: "0x00000000000000000000000000000000000000a1", Owner: "tenant"},
+	})).Return([]data.Subscription{
+		{Address: "0x00000000000000000000000000000000000000a1", Owner: "tenant"},
+	}, nil)
+
+	// act
+	added, err := service.AddUniqueMany([]data.Subscription{
+		{Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", Owner: "tenant"},
+		{Address: "0x00000000000000000000000000000000000000A1", Owner: "tenant"},
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []data.Subscription{
+		{Address: "0x00000000000000000000000000000000000000a1", Owner: "tenant"},
+	}, added)
+}
+
+func TestAddressServiceAddUniqueManyInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	// assert
+	mockStorage.EXPECT().AddMany(gomock.Any()).Times(0)
+
+	// act
+	added, err := service.AddUniqueMany([]data.Subscription{
+		{Address: "0x00000000000000000000000000000000000000a1"},
+		{Address: "addr"},
+	})
+
+	// assert
+	assert.ErrorIs(t, err, data.ErrInvalidAddress)
+	assert.Empty(t, added)
+}
+
+func TestAddressServiceRemoveMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockStorage := mockDomain.NewMockAddressStorage(ctrl)
+	service := domain.NewAddressService(mockStorage)
+
+	// assert
+	mockStorage.EXPECT().RemoveMany(gomock.Eq([]string{
+		"0x00000000000000000000000000000000000000a1",
+		"0x00000000000000000000000000000000000000a2",
+	})).Return([]string{"0x00000000000000000000000000000000000000a2"}, nil)
+
+	// act
+	removed, err := service.RemoveMany([]string{
+		"0x00000000000000000000000000000000000000A1",
+		"0x00000000000000000000000000000000000000a2",
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x00000000000000000000000000000000000000a2"}, removed)
 }
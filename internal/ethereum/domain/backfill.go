@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/rpc"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	BlockScanner interface {
+		ScanBlocks(ctx context.Context, from, to int, process func(ctx context.Context, block *rpc.Block) error) error
+	}
+
+	BackfillTransactionService interface {
+		ProcessBlockTransactionsForAddress(ctx context.Context, block *rpc.Block, address string) error
+	}
+
+	BackfillService struct {
+		scanner     BlockScanner
+		transaction BackfillTransactionService
+
+		mu       sync.Mutex
+		progress map[string]data.BackfillProgress
+		runs     map[string]backfillRun
+		wg       sync.WaitGroup
+	}
+
+	backfillRun struct {
+		cancel context.CancelFunc
+		// done is closed when goroutine of backfill exits
+		done chan struct{}
+	}
+)
+
+var (
+	ErrBackfillRunning = errors.New("backfill is already running")
+	ErrInvalidRange    = errors.New("invalid block range")
+)
+
+func NewBackfillService(scanner BlockScanner, transaction BackfillTransactionService) *BackfillService {
+	return &BackfillService{
+		scanner:     scanner,
+		transaction: transaction,
+		progress:    make(map[string]data.BackfillProgress),
+		runs:        make(map[string]backfillRun),
+	}
+}
+
+// Start scans blocks from..to for transactions of address in background, it stops when ctx is done.
+// Only one backfill runs per address, finished one may be started again. Transactions already
+// saved by live processing are skipped, so ranges may overlap.
+func (b *BackfillService) Start(ctx context.Context, address string, from, to int) error {
+	if from < 0 || to < from {
+		return fmt.Errorf("%w %d..%d", ErrInvalidRange, from, to)
+	}
+
+	address = data.NormalizeAddress(address)
+
+	b.mu.Lock()
+	if progress, ok := b.progress[address]; ok && progress.State == data.BackfillStateRunning {
+		b.mu.Unlock()
+
+		return fmt.Errorf("error starting backfill of %s: %w", address, ErrBackfillRunning)
+	}
+	b.progress[address] = data.BackfillProgress{
+		Address:      address,
+		FromBlock:    from,
+		ToBlock:      to,
+		CurrentBlock: from - 1,
+		State:        data.BackfillStateRunning,
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	b.runs[address] = backfillRun{
+		cancel: cancel,
+		done:   done,
+	}
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer close(done)
+		defer cancel()
+
+		b.run(ctx, address, from, to)
+	}()
+
+	return nil
+}
+
+// Progress returns state of the last backfill of address, ok is false when none was started.
+func (b *BackfillService) Progress(address string) (data.BackfillProgress, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	progress, ok := b.progress[data.NormalizeAddress(address)]
+
+	return progress, ok
+}
+
+// Cancel stops running backfill of address, e.g. when address is unsubscribed. It returns when
+// backfill is stopped, so nothing is saved for address after that and backfill may be started again.
+func (b *BackfillService) Cancel(address string) {
+	b.mu.Lock()
+	run, ok := b.runs[data.NormalizeAddress(address)]
+	b.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	run.cancel()
+	<-run.done
+}
+
+// Wait blocks until every started backfill is finished.
+func (b *BackfillService) Wait() {
+	b.wg.Wait()
+}
+
+func (b *BackfillService) run(ctx context.Context, address string, from, to int) {
+	logrus.
+		WithFields(logrus.Fields{
+			"address":    address,
+			"from_block": from,
+			"to_block":   to,
+		}).
+		Info("Backfill was started")
+
+	err := b.scanner.ScanBlocks(ctx, from, to, func(ctx context.Context, block *rpc.Block) error {
+		if err := b.transaction.ProcessBlockTransactionsForAddress(ctx, block, address); err != nil {
+			return fmt.Errorf("error processing block %s: %w", block.Number, err)
+		}
+
+		number, err := parseHexNumber(block.Number)
+		if err != nil {
+			return fmt.Errorf("error parsing block number %s: %w", block.Number, err)
+		}
+
+		b.update(address, func(progress *data.BackfillProgress) {
+			progress.CurrentBlock = number
+		})
+
+		return nil
+	})
+	if err != nil && ctx.Err() != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"address":       address,
+				"current_block": b.current(address),
+			}).
+			Info("Backfill was canceled")
+
+		b.finish(address, func(progress *data.BackfillProgress) {
+			progress.State = data.BackfillStateCanceled
+		})
+
+		return
+	}
+	if err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"address":    address,
+				"from_block": from,
+				"to_block":   to,
+			}).
+			WithError(err).
+			Error("failed to backfill address history")
+
+		b.finish(address, func(progress *data.BackfillProgress) {
+			progress.State = data.BackfillStateFailed
+			progress.Error = err.Error()
+		})
+
+		return
+	}
+
+	b.finish(address, func(progress *data.BackfillProgress) {
+		progress.CurrentBlock = to
+		progress.State = data.BackfillStateDone
+	})
+
+	logrus.
+		WithFields(logrus.Fields{
+			"address":    address,
+			"from_block": from,
+			"to_block":   to,
+		}).
+		Info("Backfill was finished")
+}
+
+func (b *BackfillService) update(address string, apply func(progress *data.BackfillProgress)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	progress := b.progress[address]
+	apply(&progress)
+	b.progress[address] = progress
+}
+
+// finish applies the final state and forgets the finished backfill.
+func (b *BackfillService) finish(address string, apply func(progress *data.BackfillProgress)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	progress := b.progress[address]
+	apply(&progress)
+	b.progress[address] = progress
+
+	delete(b.runs, address)
+}
+
+func (b *BackfillService) current(address string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.progress[address].CurrentBlock
+}
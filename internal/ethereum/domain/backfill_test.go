@@ -0,0 +1,202 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/domain"
+	mockDomain "trust_walet/internal/ethereum/domain/mock"
+	"trust_walet/internal/ethereum/rpc"
+)
+
+type unitBackfillService struct {
+	mockScanner            *mockDomain.MockBlockScanner
+	mockTransactionService *mockDomain.MockBackfillTransactionService
+	backfillService        *domain.BackfillService
+}
+
+func newUnitBackfillService(ctrl *gomock.Controller) *unitBackfillService {
+	unit := unitBackfillService{
+		mockScanner:            mockDomain.NewMockBlockScanner(ctrl),
+		mockTransactionService: mockDomain.NewMockBackfillTransactionService(ctrl),
+	}
+	unit.backfillService = domain.NewBackfillService(
+		unit.mockScanner,
+		unit.mockTransactionService,
+	)
+
+	return &unit
+}
+
+// scanBlocks returns ScanBlocks implementation which hands the given blocks to process.
+func scanBlocks(blocks ...*rpc.Block) func(ctx context.Context, from, to int, process func(ctx context.Context, block *rpc.Block) error) error {
+	return func(ctx context.Context, from, to int, process func(ctx context.Context, block *rpc.Block) error) error {
+		for _, block := range blocks {
+			if err := process(ctx, block); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestBackfillServiceStart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBackfillService(ctrl)
+	block1 := rpc.Block{Number: "0x1"}
+	block2 := rpc.Block{Number: "0x2"}
+
+	// assert
+	tc.mockScanner.EXPECT().ScanBlocks(gomock.Any(), gomock.Eq(1), gomock.Eq(2), gomock.Any()).DoAndReturn(scanBlocks(&block1, &block2))
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactionsForAddress(gomock.Any(), gomock.Eq(&block1), gomock.Eq("0x00000000000000000000000000000000000000a1")).Return(nil)
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactionsForAddress(gomock.Any(), gomock.Eq(&block2), gomock.Eq("0x00000000000000000000000000000000000000a1")).Return(nil)
+
+	// act
+	err := tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000A1", 1, 2)
+	tc.backfillService.Wait()
+
+	// assert
+	assert.NoError(t, err)
+
+	progress, ok := tc.backfillService.Progress("0x00000000000000000000000000000000000000a1")
+	assert.True(t, ok)
+	assert.Equal(t, data.BackfillProgress{
+		Address:      "0x00000000000000000000000000000000000000a1",
+		FromBlock:    1,
+		ToBlock:      2,
+		CurrentBlock: 2,
+		State:        data.BackfillStateDone,
+	}, progress)
+	assert.Equal(t, float64(100), progress.Percent())
+}
+
+func TestBackfillServiceStartFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBackfillService(ctrl)
+	block1 := rpc.Block{Number: "0x1"}
+	block2 := rpc.Block{Number: "0x2"}
+
+	// assert
+	tc.mockScanner.EXPECT().ScanBlocks(gomock.Any(), gomock.Eq(1), gomock.Eq(4), gomock.Any()).DoAndReturn(scanBlocks(&block1, &block2))
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactionsForAddress(gomock.Any(), gomock.Eq(&block1), gomock.Any()).Return(nil)
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactionsForAddress(gomock.Any(), gomock.Eq(&block2), gomock.Any()).Return(errors.New("error"))
+
+	// act
+	err := tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000a1", 1, 4)
+	tc.backfillService.Wait()
+
+	// assert
+	assert.NoError(t, err)
+
+	progress, ok := tc.backfillService.Progress("0x00000000000000000000000000000000000000a1")
+	assert.True(t, ok)
+	assert.Equal(t, data.BackfillStateFailed, progress.State)
+	assert.Equal(t, 1, progress.CurrentBlock)
+	assert.Equal(t, float64(25), progress.Percent())
+	assert.NotEmpty(t, progress.Error)
+}
+
+func TestBackfillServiceStartRunning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBackfillService(ctrl)
+	started := make(chan struct{})
+
+	tc.mockScanner.EXPECT().ScanBlocks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, from, to int, process func(ctx context.Context, block *rpc.Block) error) error {
+			close(started)
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+	)
+
+	err := tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000a1", 1, 2)
+	assert.NoError(t, err)
+	<-started
+
+	// act
+	err = tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000a1", 1, 2)
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrBackfillRunning)
+
+	// act
+	tc.backfillService.Cancel("0x00000000000000000000000000000000000000a1")
+	tc.backfillService.Wait()
+
+	// assert
+	progress, _ := tc.backfillService.Progress("0x00000000000000000000000000000000000000a1")
+	assert.Equal(t, data.BackfillStateCanceled, progress.State)
+}
+
+func TestBackfillServiceCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBackfillService(ctrl)
+	started := make(chan struct{})
+
+	tc.mockScanner.EXPECT().ScanBlocks(gomock.Any(), gomock.Eq(1), gomock.Eq(2), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, from, to int, process func(ctx context.Context, block *rpc.Block) error) error {
+			close(started)
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+	)
+	tc.mockScanner.EXPECT().ScanBlocks(gomock.Any(), gomock.Eq(3), gomock.Eq(4), gomock.Any()).Return(nil)
+
+	err := tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000a1", 1, 2)
+	assert.NoError(t, err)
+	<-started
+
+	// act
+	tc.backfillService.Cancel("0x00000000000000000000000000000000000000a1")
+
+	// assert
+	// backfill is stopped when Cancel returns, without waiting for it
+	progress, _ := tc.backfillService.Progress("0x00000000000000000000000000000000000000a1")
+	assert.Equal(t, data.BackfillStateCanceled, progress.State)
+
+	// act
+	err = tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000a1", 3, 4)
+	tc.backfillService.Wait()
+
+	// assert
+	assert.NoError(t, err)
+	progress, _ = tc.backfillService.Progress("0x00000000000000000000000000000000000000a1")
+	assert.Equal(t, data.BackfillStateDone, progress.State)
+}
+
+func TestBackfillServiceStartInvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBackfillService(ctrl)
+
+	// act
+	err := tc.backfillService.Start(context.Background(), "0x00000000000000000000000000000000000000a1", 5, 4)
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrInvalidRange)
+
+	_, ok := tc.backfillService.Progress("0x00000000000000000000000000000000000000a1")
+	assert.False(t, ok)
+}
@@ -5,46 +5,96 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
+	"trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/rpc"
 	"trust_walet/internal/ethereum/storage"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	DefaultReorgWindow    = 64
+	DefaultBatchThreshold = 3
+	DefaultBatchSize      = 50
+	DefaultConcurrency    = 4
+)
+
 type (
 	TransactionServiceInterface interface {
-		ProcessBlockTransactionsByBlockNumber(ctx context.Context, number int) error
+		ProcessBlockTransactions(ctx context.Context, block *rpc.Block) error
+		RetractBlockTransactions(number int) error
 	}
 
 	BlockRpcClient interface {
 		GetBlockByNumber(ctx context.Context, number string) (*rpc.Block, error)
+		GetBlocksByNumber(ctx context.Context, numbers []string) ([]*rpc.Block, error)
 	}
 
 	BlockStorage interface {
-		SetCurrentBlockNumber(value int)
+		SetCurrentBlockNumber(value int) error
 		GetCurrentBlockNumber() (int, error)
+		SaveBlock(block *data.Block) error
+		GetBlock(number int) (*data.Block, error)
+		DeleteBlocksFrom(number int) error
+		DeleteBlocksBefore(number int) error
+	}
+
+	BlockServiceConfig struct {
+		// ReorgWindow is the number of recent blocks kept to detect chain reorganizations.
+		ReorgWindow int
+		// StartBlock is used when no block was processed yet, otherwise processing
+		// continues from the block after the stored one.
+		StartBlock StartBlock
+		// BatchThreshold is the number of blocks behind latest after which blocks are fetched in batches.
+		BatchThreshold int
+		// BatchSize is the maximum number of blocks fetched in one batch request.
+		BatchSize int
+		// Concurrency is the maximum number of block requests running in parallel.
+		Concurrency int
 	}
 
 	BlockService struct {
 		client      BlockRpcClient
 		storage     BlockStorage
 		transaction TransactionServiceInterface
+		config      BlockServiceConfig
 
 		mu sync.Mutex
 	}
 )
 
+var (
+	ErrReorgTooDeep    = errors.New("chain reorganization is deeper than reorg window")
+	ErrBlockNotFetched = errors.New("block was not fetched")
+)
+
 func NewBlockService(
 	client BlockRpcClient,
 	storage BlockStorage,
 	transaction TransactionServiceInterface,
+	config BlockServiceConfig,
 ) *BlockService {
+	if config.ReorgWindow <= 0 {
+		config.ReorgWindow = DefaultReorgWindow
+	}
+	if config.BatchThreshold <= 0 {
+		config.BatchThreshold = DefaultBatchThreshold
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultConcurrency
+	}
+
 	return &BlockService{
 		client:      client,
 		storage:     storage,
 		transaction: transaction,
+		config:      config,
 	}
 }
 
@@ -85,7 +135,7 @@ func (b *BlockService) ProcessNewBlocks(ctx context.Context) error {
 		return fmt.Errorf("error fetching latest block for monitoring: %w", err)
 	}
 
-	lastNumber, err := strconv.ParseInt(block.Number[2:], 16, 0)
+	lastNumber, err := parseHexNumber(block.Number)
 	if err != nil {
 		logrus.
 			WithFields(logrus.Fields{
@@ -97,7 +147,7 @@ func (b *BlockService) ProcessNewBlocks(ctx context.Context) error {
 		return fmt.Errorf("error parsing ethereum hex to int: %w", err)
 	}
 
-	currentBlockNumber, err := b.GetCurrentNumber(int(lastNumber))
+	startBlockNumber, err := b.nextNumber(lastNumber)
 	if err != nil {
 		logrus.
 			WithError(err).
@@ -106,9 +156,52 @@ func (b *BlockService) ProcessNewBlocks(ctx context.Context) error {
 		return fmt.Errorf("failed to get current block number: %w", err)
 	}
 
-	for i := currentBlockNumber; i <= int(lastNumber); i++ {
-		err := b.transaction.ProcessBlockTransactionsByBlockNumber(ctx, i)
+	// blocks are fetched in parallel, but committed strictly in order,
+	// so a failed fetch stops processing right before the gap
+	pipeline := b.newFetchPipeline(ctx, startBlockNumber, lastNumber)
+	defer func() { pipeline.Close() }()
+
+	var fetched []*rpc.Block
+	for i := startBlockNumber; i <= lastNumber; i++ {
+		if len(fetched) == 0 {
+			fetched, err = pipeline.Next(ctx)
+			if rpc.IsBlockNotFound(err) {
+				// node which served the block lags behind the one which reported latest,
+				// the rest is processed on the next run
+				logrus.
+					WithFields(logrus.Fields{
+						"block_number": i,
+					}).
+					WithError(err).
+					Warn("Block is not available yet, processing is postponed")
+
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(fetched) == 0 {
+				return fmt.Errorf("error getting block %d for processing: %w", i, ErrBlockNotFetched)
+			}
+		}
+
+		block := fetched[0]
+		fetched = fetched[1:]
+
+		ancestor, reorged, err := b.handleReorg(ctx, i, block)
 		if err != nil {
+			return fmt.Errorf("error handling reorganization at block %d: %w", i, err)
+		}
+		if reorged {
+			// continue from the block right after the common ancestor
+			i = ancestor
+			fetched = nil
+			pipeline.Close()
+			pipeline = b.newFetchPipeline(ctx, ancestor+1, lastNumber)
+			continue
+		}
+
+		if err := b.transaction.ProcessBlockTransactions(ctx, block); err != nil {
 			logrus.
 				WithFields(logrus.Fields{
 					"block_number": i,
@@ -119,15 +212,144 @@ func (b *BlockService) ProcessNewBlocks(ctx context.Context) error {
 			return fmt.Errorf("error processing block %d: %w", i, err)
 		}
 
-		b.storage.SetCurrentBlockNumber(i)
+		// block which is not stored is processed again, its saved transactions are not duplicated
+		if err := b.saveProcessed(i, block); err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number": i,
+				}).
+				WithError(err).
+				Error("failed to save processed block")
+
+			return fmt.Errorf("error saving block %d: %w", i, err)
+		}
 	}
 
 	logrus.
 		WithFields(logrus.Fields{
-			"start_block": currentBlockNumber,
+			"start_block": startBlockNumber,
 			"end_block":   lastNumber,
 		}).
 		Info("New blocks were processed")
 
 	return nil
 }
+
+// saveProcessed stores processed block as current one and drops blocks which left the reorg window.
+func (b *BlockService) saveProcessed(number int, block *rpc.Block) error {
+	err := b.storage.SaveBlock(&data.Block{
+		Number:     number,
+		Hash:       block.Hash,
+		ParentHash: block.ParentHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := b.storage.SetCurrentBlockNumber(number); err != nil {
+		return err
+	}
+
+	return b.storage.DeleteBlocksBefore(number - b.config.ReorgWindow + 1)
+}
+
+// nextNumber returns the block after the stored one or configured start block if nothing is stored.
+func (b *BlockService) nextNumber(latest int) (int, error) {
+	value, err := b.storage.GetCurrentBlockNumber()
+	if err != nil {
+		if errors.Is(err, storage.ErrBlockCurrentNotSet) {
+			return b.config.StartBlock.Resolve(latest), nil
+		}
+
+		return 0, err
+	}
+
+	return value + 1, nil
+}
+
+// handleReorg compares fetched block with stored parent and rolls back orphaned blocks.
+// It returns the common ancestor number when a reorganization was detected.
+func (b *BlockService) handleReorg(ctx context.Context, number int, block *rpc.Block) (int, bool, error) {
+	parent, err := b.storage.GetBlock(number - 1)
+	if errors.Is(err, storage.ErrBlockNotFound) {
+		// nothing is known about the parent, e.g. it is the first processed block
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error getting stored block %d: %w", number-1, err)
+	}
+	if parent.Hash == block.ParentHash {
+		return 0, false, nil
+	}
+
+	return b.rollback(ctx, number-1)
+}
+
+// rollback retracts blocks from head down to the common ancestor with canonical chain.
+func (b *BlockService) rollback(ctx context.Context, head int) (int, bool, error) {
+	ancestor, err := b.findCommonAncestor(ctx, head)
+	if err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"block_number": head,
+			}).
+			WithError(err).
+			Error("failed to find common ancestor")
+
+		return 0, false, err
+	}
+
+	for n := head; n > ancestor; n-- {
+		if err := b.transaction.RetractBlockTransactions(n); err != nil {
+			return 0, false, fmt.Errorf("error retracting block %d: %w", n, err)
+		}
+	}
+
+	if err := b.storage.DeleteBlocksFrom(ancestor + 1); err != nil {
+		return 0, false, fmt.Errorf("error deleting blocks from %d: %w", ancestor+1, err)
+	}
+	if err := b.storage.SetCurrentBlockNumber(ancestor); err != nil {
+		return 0, false, fmt.Errorf("error setting current block %d: %w", ancestor, err)
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"ancestor_block": ancestor,
+			"head_block":     head,
+		}).
+		Warn("Chain reorganization was detected, orphaned blocks were rolled back")
+
+	return ancestor, true, nil
+}
+
+func (b *BlockService) findCommonAncestor(ctx context.Context, from int) (int, error) {
+	for n := from; n > from-b.config.ReorgWindow; n-- {
+		stored, err := b.storage.GetBlock(n)
+		if errors.Is(err, storage.ErrBlockNotFound) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error getting stored block %d: %w", n, err)
+		}
+
+		canonical, err := b.client.GetBlockByNumber(ctx, fmt.Sprintf("0x%x", n))
+		if err != nil {
+			return 0, fmt.Errorf("error getting block %d: %w", n, err)
+		}
+
+		if canonical.Hash == stored.Hash {
+			return n, nil
+		}
+	}
+
+	return 0, ErrReorgTooDeep
+}
+
+func parseHexNumber(value string) (int, error) {
+	number, err := strconv.ParseInt(strings.TrimPrefix(value, "0x"), 16, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(number), nil
+}
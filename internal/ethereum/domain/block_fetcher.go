@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"trust_walet/internal/ethereum/rpc"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	fetchResult struct {
+		blocks []*rpc.Block
+		err    error
+	}
+
+	// fetchPipeline fetches chunks of blocks in parallel and hands them out in block order.
+	// At most Concurrency chunks are fetched or waiting to be consumed at the same time.
+	fetchPipeline struct {
+		chunks []chan fetchResult
+		next   int
+		slots  chan struct{}
+		cancel context.CancelFunc
+	}
+)
+
+func (b *BlockService) newFetchPipeline(ctx context.Context, from, latest int) *fetchPipeline {
+	ctx, cancel := context.WithCancel(ctx)
+
+	chunkSize := 1
+	if latest-from+1 > b.config.BatchThreshold {
+		chunkSize = b.config.BatchSize
+	}
+
+	p := &fetchPipeline{
+		slots:  make(chan struct{}, b.config.Concurrency),
+		cancel: cancel,
+	}
+	for start := from; start <= latest; start += chunkSize {
+		p.chunks = append(p.chunks, make(chan fetchResult, 1))
+	}
+
+	go func() {
+		for i := range p.chunks {
+			select {
+			case p.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			start := from + i*chunkSize
+			end := min(start+chunkSize-1, latest)
+
+			go func(result chan<- fetchResult) {
+				// deferred send keeps consumer from waiting forever if fetch goroutine exits abnormally
+				fetched := fetchResult{err: ErrBlockNotFetched}
+				defer func() { result <- fetched }()
+
+				fetched.blocks, fetched.err = b.fetchBlocks(ctx, start, end)
+			}(p.chunks[i])
+		}
+	}()
+
+	return p
+}
+
+// Next waits for the next chunk in order, it returns nil when all chunks were consumed.
+func (p *fetchPipeline) Next(ctx context.Context) ([]*rpc.Block, error) {
+	if p.next == len(p.chunks) {
+		return nil, nil
+	}
+
+	select {
+	case result := <-p.chunks[p.next]:
+		p.next++
+		<-p.slots
+
+		return result.blocks, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops fetching of chunks which are not consumed yet.
+func (p *fetchPipeline) Close() {
+	p.cancel()
+}
+
+// fetchBlocks fetches a single block or a batch of blocks in one request.
+func (b *BlockService) fetchBlocks(ctx context.Context, from, to int) ([]*rpc.Block, error) {
+	if from == to {
+		block, err := b.client.GetBlockByNumber(ctx, fmt.Sprintf("0x%x", from))
+		if err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number": from,
+				}).
+				WithError(err).
+				Error("failed to get block by number")
+
+			return nil, fmt.Errorf("error getting block %d for processing: %w", from, err)
+		}
+
+		return []*rpc.Block{block}, nil
+	}
+
+	numbers := make([]string, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		numbers = append(numbers, fmt.Sprintf("0x%x", n))
+	}
+
+	blocks, err := b.client.GetBlocksByNumber(ctx, numbers)
+	if err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"start_block": from,
+				"end_block":   to,
+			}).
+			WithError(err).
+			Error("failed to get blocks by number")
+
+		return nil, fmt.Errorf("error getting blocks %d-%d for processing: %w", from, to, err)
+	}
+
+	return blocks, nil
+}
+
+// ScanBlocks fetches blocks from..to with the same batching and concurrency as new blocks and hands
+// them to process in order. Current block and reorg window are not touched, so it runs alongside
+// ProcessNewBlocks without waiting for it.
+func (b *BlockService) ScanBlocks(ctx context.Context, from, to int, process func(ctx context.Context, block *rpc.Block) error) error {
+	pipeline := b.newFetchPipeline(ctx, from, to)
+	defer pipeline.Close()
+
+	for {
+		blocks, err := pipeline.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if blocks == nil {
+			return nil
+		}
+
+		for _, block := range blocks {
+			if err := process(ctx, block); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -3,11 +3,13 @@ package domain_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	ethData "trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/domain"
 	mockDomain "trust_walet/internal/ethereum/domain/mock"
 	"trust_walet/internal/ethereum/rpc"
@@ -31,6 +33,7 @@ func newUnitBlockService(ctrl *gomock.Controller) *unitBlockService {
 		unit.mockClient,
 		unit.mockBlockStorage,
 		unit.mockTransactionService,
+		domain.BlockServiceConfig{},
 	)
 
 	return &unit
@@ -87,6 +90,19 @@ func TestBlockServiceGetCurrentNumberStorageError(t *testing.T) {
 	assert.Equal(t, result, 0)
 }
 
+func (u *unitBlockService) expectBlockProcessed(number int, block *rpc.Block) *gomock.Call {
+	hexNumber := fmt.Sprintf("0x%x", number)
+
+	fetched := u.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq(hexNumber)).Return(block, nil)
+	u.mockBlockStorage.EXPECT().GetBlock(gomock.Eq(number-1)).Return(nil, storage.ErrBlockNotFound).After(fetched)
+	processed := u.mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(block)).Return(nil).After(fetched)
+	u.mockBlockStorage.EXPECT().SaveBlock(gomock.Any()).After(processed)
+	setCurrent := u.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(number)).After(processed)
+	u.mockBlockStorage.EXPECT().DeleteBlocksBefore(gomock.Any()).After(processed)
+
+	return setCurrent
+}
+
 func TestBlockServiceProcessNewBlocks(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -100,13 +116,10 @@ func TestBlockServiceProcessNewBlocks(t *testing.T) {
 
 	// assert
 	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block, nil)
-	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(1, nil)
-
-	processed1 := tc.mockTransactionService.EXPECT().ProcessBlockTransactionsByBlockNumber(gomock.Any(), gomock.Eq(1)).Return(nil)
-	setCurrent1 := tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(1)).After(processed1)
-
-	processed2 := tc.mockTransactionService.EXPECT().ProcessBlockTransactionsByBlockNumber(gomock.Any(), gomock.Eq(2)).Return(nil).After(setCurrent1)
-	tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(2)).After(processed2)
+	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(0, nil)
+	setCurrent1 := tc.expectBlockProcessed(1, &rpc.Block{Number: "0x1"})
+	setCurrent2 := tc.expectBlockProcessed(2, &block)
+	setCurrent2.After(setCurrent1)
 
 	// act
 	err := tc.blockService.ProcessNewBlocks(context.Background())
@@ -128,12 +141,11 @@ func TestBlockServiceProcessNewBlocksFailedProcess(t *testing.T) {
 
 	// assert
 	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block, nil)
-	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(1, nil)
-
-	processed1 := tc.mockTransactionService.EXPECT().ProcessBlockTransactionsByBlockNumber(gomock.Any(), gomock.Eq(1)).Return(nil)
-	setCurrent1 := tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(1)).After(processed1)
-
-	tc.mockTransactionService.EXPECT().ProcessBlockTransactionsByBlockNumber(gomock.Any(), gomock.Eq(2)).
+	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(0, nil)
+	setCurrent1 := tc.expectBlockProcessed(1, &rpc.Block{Number: "0x1"})
+	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(&block, nil)
+	tc.mockBlockStorage.EXPECT().GetBlock(gomock.Any()).Return(nil, storage.ErrBlockNotFound).AnyTimes()
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block)).
 		Return(errors.New("failed to process")).
 		After(setCurrent1)
 	tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(2)).Times(0)
@@ -145,6 +157,33 @@ func TestBlockServiceProcessNewBlocksFailedProcess(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestBlockServiceProcessNewBlocksStorageError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBlockService(ctrl)
+
+	block := rpc.Block{
+		Number: "0x2",
+	}
+
+	// assert
+	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block, nil)
+	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(1, nil)
+	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(&block, nil)
+	tc.mockBlockStorage.EXPECT().GetBlock(gomock.Eq(1)).Return(nil, errors.New("any error"))
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Any()).Times(0)
+	tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Any()).Times(0)
+
+	// act
+	err := tc.blockService.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, storage.ErrBlockNotFound)
+}
+
 func TestBlockServiceProcessNewBlocksFailedGetLatest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -154,7 +193,7 @@ func TestBlockServiceProcessNewBlocksFailedGetLatest(t *testing.T) {
 
 	// assert
 	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(nil, errors.New("failed to get block"))
-	tc.mockTransactionService.EXPECT().ProcessBlockTransactionsByBlockNumber(gomock.Any(), gomock.Any()).Times(0)
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Any()).Times(0)
 	tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Any()).Times(0)
 
 	// act
@@ -178,9 +217,141 @@ func TestBlockServiceProcessNewBlocksCurrentNotSet(t *testing.T) {
 	// assert
 	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block, nil)
 	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(0, storage.ErrBlockCurrentNotSet)
+	tc.expectBlockProcessed(2, &block)
+
+	// act
+	err := tc.blockService.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestBlockServiceProcessNewBlocksReorg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+	blockStorage := storage.NewBlockInMemory()
+	blockStorage.SaveBlock(&ethData.Block{Number: 1, Hash: "h1", ParentHash: "h0"})
+	blockStorage.SaveBlock(&ethData.Block{Number: 2, Hash: "h2", ParentHash: "h1"})
+	blockStorage.SetCurrentBlockNumber(2)
+
+	service := domain.NewBlockService(mockClient, blockStorage, mockTransactionService, domain.BlockServiceConfig{})
 
-	processed := tc.mockTransactionService.EXPECT().ProcessBlockTransactionsByBlockNumber(gomock.Any(), gomock.Eq(2)).Return(nil)
-	tc.mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(2)).Times(1).After(processed)
+	block1 := rpc.Block{Number: "0x1", Hash: "h1", ParentHash: "h0"}
+	block2 := rpc.Block{Number: "0x2", Hash: "h2b", ParentHash: "h1"}
+	block3 := rpc.Block{Number: "0x3", Hash: "h3", ParentHash: "h2b"}
+
+	// assert
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block3, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x1")).Return(&block1, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(&block2, nil).Times(2)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x3")).Return(&block3, nil).Times(2)
+	retracted := mockTransactionService.EXPECT().RetractBlockTransactions(gomock.Eq(2))
+	processed2 := mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block2)).Return(nil).After(retracted)
+	mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block3)).Return(nil).After(processed2)
+
+	// act
+	err := service.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+
+	current, err := blockStorage.GetCurrentBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, current)
+
+	stored, err := blockStorage.GetBlock(2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "h2b", stored.Hash)
+	}
+}
+
+func TestBlockServiceProcessNewBlocksReorgTooDeep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+	blockStorage := storage.NewBlockInMemory()
+	blockStorage.SaveBlock(&ethData.Block{Number: 1, Hash: "h1", ParentHash: "h0"})
+	blockStorage.SaveBlock(&ethData.Block{Number: 2, Hash: "h2", ParentHash: "h1"})
+	blockStorage.SetCurrentBlockNumber(2)
+
+	service := domain.NewBlockService(mockClient, blockStorage, mockTransactionService, domain.BlockServiceConfig{})
+
+	block1 := rpc.Block{Number: "0x1", Hash: "h1b", ParentHash: "h0b"}
+	block2 := rpc.Block{Number: "0x2", Hash: "h2b", ParentHash: "h1b"}
+	block3 := rpc.Block{Number: "0x3", Hash: "h3", ParentHash: "h2b"}
+
+	// assert
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block3, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x3")).Return(&block3, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(&block2, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x1")).Return(&block1, nil)
+	mockTransactionService.EXPECT().RetractBlockTransactions(gomock.Any()).Times(0)
+	mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := service.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrReorgTooDeep)
+}
+
+func TestBlockServiceProcessNewBlocksStartBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockBlockStorage := mockDomain.NewMockBlockStorage(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+	service := domain.NewBlockService(mockClient, mockBlockStorage, mockTransactionService, domain.BlockServiceConfig{
+		StartBlock: domain.StartBlock{Mode: domain.StartBehindLatest, Value: 1},
+	})
+
+	block1 := rpc.Block{Number: "0x1"}
+	block2 := rpc.Block{Number: "0x2"}
+
+	// assert
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block2, nil)
+	mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(0, storage.ErrBlockCurrentNotSet)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x1")).Return(&block1, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(&block2, nil)
+	mockBlockStorage.EXPECT().GetBlock(gomock.Any()).Return(nil, storage.ErrBlockNotFound).AnyTimes()
+	processed1 := mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block1)).Return(nil)
+	mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block2)).Return(nil).After(processed1)
+	mockBlockStorage.EXPECT().SaveBlock(gomock.Any()).Times(2)
+	mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(1))
+	mockBlockStorage.EXPECT().SetCurrentBlockNumber(gomock.Eq(2))
+	mockBlockStorage.EXPECT().DeleteBlocksBefore(gomock.Any()).Times(2)
+
+	// act
+	err := service.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestBlockServiceProcessNewBlocksNothingNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBlockService(ctrl)
+
+	block := rpc.Block{
+		Number: "0x2",
+	}
+
+	// assert
+	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block, nil)
+	tc.mockBlockStorage.EXPECT().GetCurrentBlockNumber().Return(2, nil)
+	tc.mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Any()).Times(0)
 
 	// act
 	err := tc.blockService.ProcessNewBlocks(context.Background())
@@ -188,3 +359,166 @@ func TestBlockServiceProcessNewBlocksCurrentNotSet(t *testing.T) {
 	// assert
 	assert.NoError(t, err)
 }
+
+func TestBlockServiceProcessNewBlocksBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+	blockStorage := storage.NewBlockInMemory()
+	blockStorage.SetCurrentBlockNumber(0)
+
+	service := domain.NewBlockService(mockClient, blockStorage, mockTransactionService, domain.BlockServiceConfig{
+		BatchThreshold: 1,
+		BatchSize:      2,
+	})
+
+	block1 := rpc.Block{Number: "0x1", Hash: "h1"}
+	block2 := rpc.Block{Number: "0x2", Hash: "h2", ParentHash: "h1"}
+	block3 := rpc.Block{Number: "0x3", Hash: "h3", ParentHash: "h2"}
+
+	// assert
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block3, nil)
+	mockClient.EXPECT().GetBlocksByNumber(gomock.Any(), gomock.Eq([]string{"0x1", "0x2"})).Return([]*rpc.Block{&block1, &block2}, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x3")).Return(&block3, nil)
+	mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	// act
+	err := service.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+
+	current, err := blockStorage.GetCurrentBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, current)
+}
+
+func TestBlockServiceProcessNewBlocksConcurrentFetchFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+	blockStorage := storage.NewBlockInMemory()
+	blockStorage.SetCurrentBlockNumber(0)
+
+	service := domain.NewBlockService(mockClient, blockStorage, mockTransactionService, domain.BlockServiceConfig{
+		BatchThreshold: 10,
+		Concurrency:    3,
+	})
+
+	block1 := rpc.Block{Number: "0x1", Hash: "h1"}
+	block3 := rpc.Block{Number: "0x3", Hash: "h3", ParentHash: "h2"}
+
+	// assert
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block3, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x1")).Return(&block1, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(nil, errors.New("failed to get block"))
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x3")).Return(&block3, nil).MaxTimes(1)
+	mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block1)).Return(nil)
+
+	// act
+	err := service.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.Error(t, err)
+
+	current, err := blockStorage.GetCurrentBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, current)
+}
+
+func TestBlockServiceProcessNewBlocksNotFoundPostponed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+	blockStorage := storage.NewBlockInMemory()
+	blockStorage.SetCurrentBlockNumber(0)
+
+	service := domain.NewBlockService(mockClient, blockStorage, mockTransactionService, domain.BlockServiceConfig{
+		BatchThreshold: 10,
+		Concurrency:    1,
+	})
+
+	block1 := rpc.Block{Number: "0x1", Hash: "h1"}
+	block2 := rpc.Block{Number: "0x2", Hash: "h2", ParentHash: "h1"}
+
+	// assert
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("latest")).Return(&block2, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x1")).Return(&block1, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(nil, rpc.ErrBlockNotFound)
+	mockTransactionService.EXPECT().ProcessBlockTransactions(gomock.Any(), gomock.Eq(&block1)).Return(nil)
+
+	// act
+	err := service.ProcessNewBlocks(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+
+	current, err := blockStorage.GetCurrentBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, current)
+}
+
+func TestBlockServiceScanBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	mockClient := mockDomain.NewMockBlockRpcClient(ctrl)
+	mockBlockStorage := mockDomain.NewMockBlockStorage(ctrl)
+	mockTransactionService := mockDomain.NewMockTransactionServiceInterface(ctrl)
+
+	service := domain.NewBlockService(mockClient, mockBlockStorage, mockTransactionService, domain.BlockServiceConfig{
+		BatchThreshold: 1,
+		BatchSize:      2,
+	})
+
+	block1 := rpc.Block{Number: "0x1"}
+	block2 := rpc.Block{Number: "0x2"}
+	block3 := rpc.Block{Number: "0x3"}
+
+	// assert
+	mockClient.EXPECT().GetBlocksByNumber(gomock.Any(), gomock.Eq([]string{"0x1", "0x2"})).Return([]*rpc.Block{&block1, &block2}, nil)
+	mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x3")).Return(&block3, nil)
+
+	// act
+	var scanned []string
+	err := service.ScanBlocks(context.Background(), 1, 3, func(ctx context.Context, block *rpc.Block) error {
+		scanned = append(scanned, block.Number)
+
+		return nil
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x1", "0x2", "0x3"}, scanned)
+}
+
+func TestBlockServiceScanBlocksProcessFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitBlockService(ctrl)
+	block1 := rpc.Block{Number: "0x1"}
+
+	// assert
+	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x1")).Return(&block1, nil)
+	tc.mockClient.EXPECT().GetBlockByNumber(gomock.Any(), gomock.Eq("0x2")).Return(&rpc.Block{Number: "0x2"}, nil).MaxTimes(1)
+
+	// act
+	err := tc.blockService.ScanBlocks(context.Background(), 1, 2, func(ctx context.Context, block *rpc.Block) error {
+		return errors.New("error")
+	})
+
+	// assert
+	assert.Error(t, err)
+}
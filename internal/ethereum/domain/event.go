@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"trust_walet/internal/ethereum/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultEventBuffer = 100
+
+type (
+	// EventService fans out saved transactions to event subscribers, each of them has its own
+	// buffer, so a slow subscriber affects others only with BackpressureBlock.
+	EventService struct {
+		mu          sync.RWMutex
+		subscribers map[*EventSubscription]struct{}
+	}
+
+	// EventSubscription delivers events to Events channel until its context is done or, with
+	// BackpressureError, its buffer overflows. Channel is closed then and Err tells the reason.
+	EventSubscription struct {
+		ctx     context.Context
+		service *EventService
+		options data.EventOptions
+		events  chan data.TransactionEvent
+		dropped atomic.Int64
+
+		// mu serializes sends with closing of events channel, it is held while BackpressureBlock
+		// send waits for subscriber, so it is not taken by methods called by subscriber
+		mu     sync.Mutex
+		closed bool
+		// err is set before done is closed and read only after that
+		err  error
+		done chan struct{}
+	}
+)
+
+var (
+	ErrEventOverflow       = errors.New("event buffer overflow")
+	ErrInvalidBackpressure = errors.New("invalid backpressure policy")
+)
+
+func NewEventService() *EventService {
+	return &EventService{
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// Subscribe registers subscriber which receives events until ctx is done.
+// Zero options mean every address, DefaultEventBuffer and BackpressureBlock.
+func (e *EventService) Subscribe(ctx context.Context, options data.EventOptions) (*EventSubscription, error) {
+	if options.Address != "" {
+		address, err := data.ParseAddress(options.Address)
+		if err != nil {
+			return nil, fmt.Errorf("error subscribing to events: %w", err)
+		}
+		options.Address = address.String()
+	}
+	if options.Buffer <= 0 {
+		options.Buffer = DefaultEventBuffer
+	}
+	switch options.Backpressure {
+	case "":
+		options.Backpressure = data.BackpressureBlock
+	case data.BackpressureBlock, data.BackpressureDropOldest, data.BackpressureError:
+	default:
+		return nil, fmt.Errorf("error subscribing to events: %w %q", ErrInvalidBackpressure, options.Backpressure)
+	}
+
+	subscription := &EventSubscription{
+		ctx:     ctx,
+		service: e,
+		options: options,
+		events:  make(chan data.TransactionEvent, options.Buffer),
+		done:    make(chan struct{}),
+	}
+
+	e.mu.Lock()
+	e.subscribers[subscription] = struct{}{}
+	e.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			subscription.close(nil)
+		case <-subscription.done:
+		}
+	}()
+
+	logrus.
+		WithFields(logrus.Fields{
+			"address":      options.Address,
+			"buffer":       options.Buffer,
+			"backpressure": options.Backpressure,
+		}).
+		Info("Event subscriber was added")
+
+	return subscription, nil
+}
+
+// Publish sends saved transaction of address to every subscriber interested in it.
+func (e *EventService) Publish(address string, transaction *data.Transaction) {
+	e.publish(data.TransactionEvent{
+		Kind:        data.EventKindSaved,
+		Address:     address,
+		Transaction: *transaction,
+	})
+}
+
+// Retract sends transaction of address removed by chain reorganization to every subscriber interested in it.
+func (e *EventService) Retract(address string, transaction *data.Transaction) {
+	e.publish(data.TransactionEvent{
+		Kind:        data.EventKindRetracted,
+		Address:     address,
+		Transaction: *transaction,
+	})
+}
+
+func (e *EventService) publish(event data.TransactionEvent) {
+	e.mu.RLock()
+	subscribers := make([]*EventSubscription, 0, len(e.subscribers))
+	for subscription := range e.subscribers {
+		subscribers = append(subscribers, subscription)
+	}
+	e.mu.RUnlock()
+
+	for _, subscription := range subscribers {
+		if subscription.options.Address == "" || subscription.options.Address == event.Address {
+			subscription.send(event)
+		}
+	}
+}
+
+func (e *EventService) remove(subscription *EventSubscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.subscribers, subscription)
+}
+
+// Events returns channel of events, it is closed when subscription ends.
+func (s *EventSubscription) Events() <-chan data.TransactionEvent {
+	return s.events
+}
+
+// Err returns ErrEventOverflow when subscription was closed because of full buffer,
+// it is nil while subscription is active or after its context was done.
+func (s *EventSubscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Dropped returns the number of events dropped with BackpressureDropOldest.
+func (s *EventSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *EventSubscription) send(event data.TransactionEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.options.Backpressure {
+	case data.BackpressureBlock:
+		// context is checked as well, so cancelled subscriber never blocks processing
+		select {
+		case s.events <- event:
+		case <-s.ctx.Done():
+		}
+	case data.BackpressureDropOldest:
+		for {
+			select {
+			case s.events <- event:
+				return
+			default:
+			}
+
+			select {
+			case <-s.events:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	case data.BackpressureError:
+		select {
+		case s.events <- event:
+		default:
+			logrus.
+				WithFields(logrus.Fields{
+					"address": s.options.Address,
+					"buffer":  s.options.Buffer,
+				}).
+				Warn("Event subscriber was closed on buffer overflow")
+
+			s.closeLocked(ErrEventOverflow)
+		}
+	}
+}
+
+func (s *EventSubscription) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closeLocked(err)
+}
+
+func (s *EventSubscription) closeLocked(err error) {
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	s.err = err
+	close(s.events)
+	close(s.done)
+
+	// publisher holds no lock of service while sending, so it is safe to take it here
+	s.service.remove(s)
+}
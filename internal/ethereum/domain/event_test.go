@@ -0,0 +1,205 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/domain"
+)
+
+func TestEventServicePublish(t *testing.T) {
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := domain.NewEventService()
+	all, err := service.Subscribe(ctx, data.EventOptions{})
+	assert.NoError(t, err)
+	single, err := service.Subscribe(ctx, data.EventOptions{Address: "0x00000000000000000000000000000000000000A2"})
+	assert.NoError(t, err)
+
+	// act
+	service.Publish("0x00000000000000000000000000000000000000a1", &data.Transaction{Hash: "hash1"})
+	service.Publish("0x00000000000000000000000000000000000000a2", &data.Transaction{Hash: "hash2"})
+
+	// assert
+	assert.Equal(t, "hash1", (<-all.Events()).Transaction.Hash)
+	assert.Equal(t, "hash2", (<-all.Events()).Transaction.Hash)
+	assert.Equal(t, data.TransactionEvent{
+		Kind:        data.EventKindSaved,
+		Address:     "0x00000000000000000000000000000000000000a2",
+		Transaction: data.Transaction{Hash: "hash2"},
+	}, <-single.Events())
+	assert.Empty(t, single.Events())
+}
+
+func TestEventServiceRetract(t *testing.T) {
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := domain.NewEventService()
+	subscription, err := service.Subscribe(ctx, data.EventOptions{})
+	assert.NoError(t, err)
+
+	// act
+	service.Publish("addr", &data.Transaction{Hash: "hash1"})
+	service.Retract("addr", &data.Transaction{Hash: "hash1"})
+
+	// assert
+	assert.Equal(t, data.EventKindSaved, (<-subscription.Events()).Kind)
+	assert.Equal(t, data.TransactionEvent{
+		Kind:        data.EventKindRetracted,
+		Address:     "addr",
+		Transaction: data.Transaction{Hash: "hash1"},
+	}, <-subscription.Events())
+}
+
+func TestEventServiceDropOldest(t *testing.T) {
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := domain.NewEventService()
+	subscription, err := service.Subscribe(ctx, data.EventOptions{Buffer: 2, Backpressure: data.BackpressureDropOldest})
+	assert.NoError(t, err)
+
+	// act
+	for _, hash := range []string{"hash1", "hash2", "hash3"} {
+		service.Publish("addr", &data.Transaction{Hash: hash})
+	}
+
+	// assert
+	assert.Equal(t, "hash2", (<-subscription.Events()).Transaction.Hash)
+	assert.Equal(t, "hash3", (<-subscription.Events()).Transaction.Hash)
+	assert.Equal(t, int64(1), subscription.Dropped())
+	assert.NoError(t, subscription.Err())
+}
+
+func TestEventServiceOverflowError(t *testing.T) {
+	// arrange
+	service := domain.NewEventService()
+	subscription, err := service.Subscribe(context.Background(), data.EventOptions{Buffer: 1, Backpressure: data.BackpressureError})
+	assert.NoError(t, err)
+
+	// act
+	service.Publish("addr", &data.Transaction{Hash: "hash1"})
+	service.Publish("addr", &data.Transaction{Hash: "hash2"})
+	service.Publish("addr", &data.Transaction{Hash: "hash3"})
+
+	// assert
+	var hashes []string
+	for event := range subscription.Events() {
+		hashes = append(hashes, event.Transaction.Hash)
+	}
+	assert.Equal(t, []string{"hash1"}, hashes)
+	assert.ErrorIs(t, subscription.Err(), domain.ErrEventOverflow)
+}
+
+func TestEventServiceBlockUntilCanceled(t *testing.T) {
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+
+	service := domain.NewEventService()
+	subscription, err := service.Subscribe(ctx, data.EventOptions{Buffer: 1})
+	assert.NoError(t, err)
+	service.Publish("addr", &data.Transaction{Hash: "hash1"})
+
+	published := make(chan struct{})
+	go func() {
+		service.Publish("addr", &data.Transaction{Hash: "hash2"})
+		close(published)
+	}()
+
+	// assert
+	select {
+	case <-published:
+		t.Fatal("publish did not wait for subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// act
+	cancel()
+
+	// assert
+	<-published
+
+	// channel is closed, buffered events are still received
+	var hashes []string
+	for event := range subscription.Events() {
+		hashes = append(hashes, event.Transaction.Hash)
+	}
+	assert.Equal(t, []string{"hash1"}, hashes)
+	assert.NoError(t, subscription.Err())
+}
+
+func TestEventServiceErrWhilePublishBlocked(t *testing.T) {
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := domain.NewEventService()
+	subscription, err := service.Subscribe(ctx, data.EventOptions{Buffer: 1})
+	assert.NoError(t, err)
+	service.Publish("addr", &data.Transaction{Hash: "hash1"})
+
+	published := make(chan struct{})
+	go func() {
+		service.Publish("addr", &data.Transaction{Hash: "hash2"})
+		close(published)
+	}()
+
+	// act
+	errs := make(chan error)
+	go func() {
+		// subscriber checks the reason before taking buffered events
+		time.Sleep(20 * time.Millisecond)
+		errs <- subscription.Err()
+	}()
+
+	// assert
+	select {
+	case err := <-errs:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Err waited for blocked publish")
+	}
+
+	assert.Equal(t, "hash1", (<-subscription.Events()).Transaction.Hash)
+	assert.Equal(t, "hash2", (<-subscription.Events()).Transaction.Hash)
+	<-published
+}
+
+func TestEventServiceSubscribeInvalidOptions(t *testing.T) {
+	testCases := map[string]struct {
+		options data.EventOptions
+		err     error
+	}{
+		"address": {
+			options: data.EventOptions{Address: "addr"},
+			err:     data.ErrInvalidAddress,
+		},
+		"backpressure": {
+			options: data.EventOptions{Backpressure: "wait"},
+			err:     domain.ErrInvalidBackpressure,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			service := domain.NewEventService()
+
+			// act
+			subscription, err := service.Subscribe(context.Background(), tc.options)
+
+			// assert
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, subscription)
+		})
+	}
+}
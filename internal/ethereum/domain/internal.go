@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/rpc"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrTraceMismatch = errors.New("trace does not belong to block")
+
+// internalCall is value transfer made by contract call, index is the number of the call
+// in call tree of transaction in depth first order, the transaction itself is call 0.
+type internalCall struct {
+	from  string
+	to    string
+	value string
+	index int
+}
+
+// matchInternalTransfers traces calls of block transactions and returns internal ETH transfers
+// where matched address is the sender or the receiver.
+func (t *TransactionService) matchInternalTransfers(ctx context.Context, block *rpc.Block, number int, timestamp int64, match func(address string) bool) ([]addressTransaction, error) {
+	calls, err := t.internalCalls(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []addressTransaction
+	for index, tx := range block.Transactions {
+		for _, call := range calls[tx.Hash] {
+			for _, a := range []string{call.from, call.to} {
+				if a == "" || !match(a) {
+					continue
+				}
+
+				transaction, err := newTransaction(block, number, timestamp, index, &tx)
+				if err != nil {
+					return nil, fmt.Errorf("error decoding transaction %s: %w", tx.Hash, err)
+				}
+				transaction.Kind = data.TransferKindInternal
+				transaction.From = call.from
+				transaction.To = call.to
+				transaction.Value = call.value
+				transaction.EventIndex = call.index
+
+				if t.transation.Exists(a, transaction.ID()) {
+					continue
+				}
+
+				matches = append(matches, addressTransaction{
+					address:     a,
+					transaction: transaction,
+				})
+			}
+		}
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"block_number": number,
+			"matches":      len(matches),
+		}).
+		Debug("Block internal transfers were matched")
+
+	return matches, nil
+}
+
+// internalCalls returns value transfers of block by transaction hash, trace_block is used
+// when node does not provide debug API.
+func (t *TransactionService) internalCalls(ctx context.Context, block *rpc.Block) (map[string][]internalCall, error) {
+	traces, err := t.client.TraceBlockByNumber(ctx, block.Number)
+	if rpc.IsMethodNotFound(err) {
+		flat, err := t.client.TraceBlock(ctx, block.Number)
+		if err != nil {
+			return nil, fmt.Errorf("error tracing block: %w", err)
+		}
+
+		return flatInternalCalls(block, flat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error tracing block: %w", err)
+	}
+
+	if len(traces) != len(block.Transactions) {
+		return nil, fmt.Errorf("error tracing block: %d traces for %d transactions: %w", len(traces), len(block.Transactions), ErrTraceMismatch)
+	}
+
+	calls := make(map[string][]internalCall)
+	for i, trace := range traces {
+		hash := block.Transactions[i].Hash
+		if trace.TxHash != "" && trace.TxHash != hash {
+			return nil, fmt.Errorf("error tracing transaction %s: %w", hash, ErrTraceMismatch)
+		}
+		if trace.Error != "" || trace.Result == nil {
+			return nil, fmt.Errorf("error tracing transaction %s: %s", hash, trace.Error)
+		}
+
+		index := 0
+		calls[hash] = walkCallFrame(trace.Result, &index, nil)
+	}
+
+	return calls, nil
+}
+
+// walkCallFrame collects value transfers of calls made by frame. Every call is numbered,
+// calls of reverted frame are numbered too, but they transfer nothing.
+func walkCallFrame(frame *rpc.CallFrame, index *int, calls []internalCall) []internalCall {
+	current := *index
+	*index++
+
+	reverted := frame.Error != ""
+	if !reverted && current > 0 && isValueTransfer(frame.Type, frame.Value) {
+		calls = append(calls, internalCall{
+			from:  data.NormalizeAddress(frame.From),
+			to:    data.NormalizeAddress(frame.To),
+			value: frame.Value,
+			index: current,
+		})
+	}
+
+	for i := range frame.Calls {
+		nested := walkCallFrame(&frame.Calls[i], index, nil)
+		if !reverted {
+			calls = append(calls, nested...)
+		}
+	}
+
+	return calls
+}
+
+// flatInternalCalls collects value transfers from trace_block result, which lists calls
+// of every transaction in depth first order.
+func flatInternalCalls(block *rpc.Block, traces []*rpc.Trace) (map[string][]internalCall, error) {
+	calls := make(map[string][]internalCall)
+	indexes := make(map[string]int)
+	reverted := make(map[string][][]int)
+
+	for _, trace := range traces {
+		// block reward traces have no transaction
+		if trace.TransactionHash == "" {
+			continue
+		}
+		if trace.BlockHash != "" && block.Hash != "" && trace.BlockHash != block.Hash {
+			return nil, fmt.Errorf("error tracing transaction %s: %w", trace.TransactionHash, ErrTraceMismatch)
+		}
+
+		hash := trace.TransactionHash
+		index := indexes[hash]
+		indexes[hash]++
+
+		if trace.Error != "" {
+			reverted[hash] = append(reverted[hash], trace.TraceAddress)
+			continue
+		}
+		if index == 0 || slices.ContainsFunc(reverted[hash], func(address []int) bool {
+			return len(trace.TraceAddress) > len(address) && slices.Equal(trace.TraceAddress[:len(address)], address)
+		}) {
+			continue
+		}
+
+		call := internalCall{index: index}
+		switch trace.Type {
+		case "call":
+			if trace.Action.CallType != "call" {
+				continue
+			}
+			call.from, call.to, call.value = trace.Action.From, trace.Action.To, trace.Action.Value
+		case "create":
+			call.from, call.value = trace.Action.From, trace.Action.Value
+			if trace.Result != nil {
+				call.to = trace.Result.Address
+			}
+		case "suicide":
+			call.from, call.to, call.value = trace.Action.Address, trace.Action.RefundAddress, trace.Action.Balance
+		default:
+			continue
+		}
+
+		if isPositive(call.value) {
+			call.from, call.to = data.NormalizeAddress(call.from), data.NormalizeAddress(call.to)
+			calls[hash] = append(calls[hash], call)
+		}
+	}
+
+	return calls, nil
+}
+
+// isValueTransfer tells whether call of callTracer type moves ETH, delegate and static calls never do.
+func isValueTransfer(callType, value string) bool {
+	switch strings.ToUpper(callType) {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		return isPositive(value)
+	}
+
+	return false
+}
+
+func isPositive(value string) bool {
+	amount, ok := new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+
+	return ok && amount.Sign() > 0
+}
@@ -0,0 +1,131 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/domain"
+	"trust_walet/internal/ethereum/rpc"
+)
+
+func internalTestBlock() *rpc.Block {
+	return &rpc.Block{
+		Number: "0xa",
+		Hash:   "block_hash",
+		Transactions: []rpc.Transaction{
+			{Hash: "tx", From: "eoa", To: "multisig", GasPrice: "0x2"},
+		},
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsInternalTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{InternalTransfers: true})
+
+	var saved []*data.Transaction
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("wallet")).Return(true).AnyTimes()
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("wallet"), gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().TraceBlockByNumber(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.TransactionTrace{{
+		TxHash: "tx",
+		Result: &rpc.CallFrame{Type: "CALL", From: "eoa", To: "multisig", Value: "0x0", Calls: []rpc.CallFrame{
+			{Type: "DELEGATECALL", From: "multisig", To: "library", Value: "0x5"},
+			{Type: "CALL", From: "multisig", To: "wallet", Value: "0x0"},
+			{Type: "CALL", From: "multisig", To: "other", Error: "execution reverted", Calls: []rpc.CallFrame{
+				{Type: "CALL", From: "other", To: "wallet", Value: "0x7"},
+			}},
+			{Type: "CALL", From: "multisig", To: "wallet", Value: "0xde0b6b3a7640000"},
+		}},
+	}}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("wallet"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved = append(saved, tx)
+		})
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), internalTestBlock())
+
+	// assert
+	assert.NoError(t, err)
+	if assert.Len(t, saved, 1) {
+		assert.Equal(t, data.TransferKindInternal, saved[0].Kind)
+		assert.Equal(t, "tx", saved[0].Hash)
+		assert.Equal(t, "multisig", saved[0].From)
+		assert.Equal(t, "wallet", saved[0].To)
+		assert.Equal(t, "0xde0b6b3a7640000", saved[0].Value)
+		assert.Equal(t, 5, saved[0].EventIndex)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsInternalTransfersTraceBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{InternalTransfers: true})
+
+	var saved []*data.Transaction
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("wallet")).Return(true).AnyTimes()
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("wallet"), gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().TraceBlockByNumber(gomock.Any(), gomock.Eq("0xa")).
+		Return(nil, &rpc.Error{Code: rpc.CodeMethodNotFound, Message: "the method debug_traceBlockByNumber does not exist"})
+	tc.mockClient.EXPECT().TraceBlock(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.Trace{
+		{Type: "call", Action: rpc.TraceAction{CallType: "call", From: "eoa", To: "multisig"}, TraceAddress: []int{}, TransactionHash: "tx"},
+		{Type: "call", Action: rpc.TraceAction{CallType: "call", From: "multisig", To: "other"}, Error: "Reverted", TraceAddress: []int{0}, TransactionHash: "tx"},
+		{Type: "call", Action: rpc.TraceAction{CallType: "call", From: "other", To: "wallet", Value: "0x7"}, TraceAddress: []int{0, 0}, TransactionHash: "tx"},
+		{Type: "call", Action: rpc.TraceAction{CallType: "call", From: "multisig", To: "wallet", Value: "0x9"}, TraceAddress: []int{1}, TransactionHash: "tx"},
+		{Type: "suicide", Action: rpc.TraceAction{Address: "multisig", RefundAddress: "wallet", Balance: "0x3"}, TraceAddress: []int{2}, TransactionHash: "tx"},
+		{Type: "reward", Action: rpc.TraceAction{Value: "0x1"}},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("wallet"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved = append(saved, tx)
+		}).
+		Times(2)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), internalTestBlock())
+
+	// assert
+	assert.NoError(t, err)
+	if assert.Len(t, saved, 2) {
+		assert.Equal(t, "0x9", saved[0].Value)
+		assert.Equal(t, 3, saved[0].EventIndex)
+		assert.Equal(t, "0x3", saved[1].Value)
+		assert.Equal(t, "multisig", saved[1].From)
+		assert.Equal(t, 4, saved[1].EventIndex)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsInternalTransfersOfOtherBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{InternalTransfers: true})
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().TraceBlockByNumber(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.TransactionTrace{
+		{TxHash: "another", Result: &rpc.CallFrame{Type: "CALL"}},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), internalTestBlock())
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrTraceMismatch)
+}
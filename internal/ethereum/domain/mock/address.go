@@ -11,6 +11,7 @@ package mockDomain
 
 import (
 	reflect "reflect"
+	data "trust_walet/internal/ethereum/data"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -39,15 +40,33 @@ func (m *MockAddressStorage) EXPECT() *MockAddressStorageMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockAddressStorage) Add(address string) {
+func (m *MockAddressStorage) Add(subscription data.Subscription) (bool, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Add", address)
+	ret := m.ctrl.Call(m, "Add", subscription)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Add indicates an expected call of Add.
-func (mr *MockAddressStorageMockRecorder) Add(address any) *gomock.Call {
+func (mr *MockAddressStorageMockRecorder) Add(subscription any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockAddressStorage)(nil).Add), subscription)
+}
+
+// AddMany mocks base method.
+func (m *MockAddressStorage) AddMany(subscriptions []data.Subscription) ([]data.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMany", subscriptions)
+	ret0, _ := ret[0].([]data.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddMany indicates an expected call of AddMany.
+func (mr *MockAddressStorageMockRecorder) AddMany(subscriptions any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockAddressStorage)(nil).Add), address)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMany", reflect.TypeOf((*MockAddressStorage)(nil).AddMany), subscriptions)
 }
 
 // Exists mocks base method.
@@ -63,3 +82,47 @@ func (mr *MockAddressStorageMockRecorder) Exists(address any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockAddressStorage)(nil).Exists), address)
 }
+
+// List mocks base method.
+func (m *MockAddressStorage) List() []data.Subscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]data.Subscription)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockAddressStorageMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAddressStorage)(nil).List))
+}
+
+// Remove mocks base method.
+func (m *MockAddressStorage) Remove(address string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", address)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockAddressStorageMockRecorder) Remove(address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockAddressStorage)(nil).Remove), address)
+}
+
+// RemoveMany mocks base method.
+func (m *MockAddressStorage) RemoveMany(addresses []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMany", addresses)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveMany indicates an expected call of RemoveMany.
+func (mr *MockAddressStorageMockRecorder) RemoveMany(addresses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMany", reflect.TypeOf((*MockAddressStorage)(nil).RemoveMany), addresses)
+}
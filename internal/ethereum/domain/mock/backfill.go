@@ -0,0 +1,92 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ethereum/domain/backfill.go
+//
+// Generated by this command:
+//
+//	mockgen -source internal/ethereum/domain/backfill.go -destination internal/ethereum/domain/mock/backfill.go -package=mockDomain
+//
+
+// Package mockDomain is a generated GoMock package.
+package mockDomain
+
+import (
+	context "context"
+	reflect "reflect"
+	rpc "trust_walet/internal/ethereum/rpc"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBlockScanner is a mock of BlockScanner interface.
+type MockBlockScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlockScannerMockRecorder
+}
+
+// MockBlockScannerMockRecorder is the mock recorder for MockBlockScanner.
+type MockBlockScannerMockRecorder struct {
+	mock *MockBlockScanner
+}
+
+// NewMockBlockScanner creates a new mock instance.
+func NewMockBlockScanner(ctrl *gomock.Controller) *MockBlockScanner {
+	mock := &MockBlockScanner{ctrl: ctrl}
+	mock.recorder = &MockBlockScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlockScanner) EXPECT() *MockBlockScannerMockRecorder {
+	return m.recorder
+}
+
+// ScanBlocks mocks base method.
+func (m *MockBlockScanner) ScanBlocks(ctx context.Context, from, to int, process func(context.Context, *rpc.Block) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanBlocks", ctx, from, to, process)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScanBlocks indicates an expected call of ScanBlocks.
+func (mr *MockBlockScannerMockRecorder) ScanBlocks(ctx, from, to, process any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanBlocks", reflect.TypeOf((*MockBlockScanner)(nil).ScanBlocks), ctx, from, to, process)
+}
+
+// MockBackfillTransactionService is a mock of BackfillTransactionService interface.
+type MockBackfillTransactionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackfillTransactionServiceMockRecorder
+}
+
+// MockBackfillTransactionServiceMockRecorder is the mock recorder for MockBackfillTransactionService.
+type MockBackfillTransactionServiceMockRecorder struct {
+	mock *MockBackfillTransactionService
+}
+
+// NewMockBackfillTransactionService creates a new mock instance.
+func NewMockBackfillTransactionService(ctrl *gomock.Controller) *MockBackfillTransactionService {
+	mock := &MockBackfillTransactionService{ctrl: ctrl}
+	mock.recorder = &MockBackfillTransactionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackfillTransactionService) EXPECT() *MockBackfillTransactionServiceMockRecorder {
+	return m.recorder
+}
+
+// ProcessBlockTransactionsForAddress mocks base method.
+func (m *MockBackfillTransactionService) ProcessBlockTransactionsForAddress(ctx context.Context, block *rpc.Block, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessBlockTransactionsForAddress", ctx, block, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessBlockTransactionsForAddress indicates an expected call of ProcessBlockTransactionsForAddress.
+func (mr *MockBackfillTransactionServiceMockRecorder) ProcessBlockTransactionsForAddress(ctx, block, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBlockTransactionsForAddress", reflect.TypeOf((*MockBackfillTransactionService)(nil).ProcessBlockTransactionsForAddress), ctx, block, address)
+}
@@ -12,6 +12,7 @@ package mockDomain
 import (
 	context "context"
 	reflect "reflect"
+	data "trust_walet/internal/ethereum/data"
 	rpc "trust_walet/internal/ethereum/rpc"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,18 +41,32 @@ func (m *MockTransactionServiceInterface) EXPECT() *MockTransactionServiceInterf
 	return m.recorder
 }
 
-// ProcessBlockTransactionsByBlockNumber mocks base method.
-func (m *MockTransactionServiceInterface) ProcessBlockTransactionsByBlockNumber(ctx context.Context, number int) error {
+// ProcessBlockTransactions mocks base method.
+func (m *MockTransactionServiceInterface) ProcessBlockTransactions(ctx context.Context, block *rpc.Block) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ProcessBlockTransactionsByBlockNumber", ctx, number)
+	ret := m.ctrl.Call(m, "ProcessBlockTransactions", ctx, block)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// ProcessBlockTransactionsByBlockNumber indicates an expected call of ProcessBlockTransactionsByBlockNumber.
-func (mr *MockTransactionServiceInterfaceMockRecorder) ProcessBlockTransactionsByBlockNumber(ctx, number any) *gomock.Call {
+// ProcessBlockTransactions indicates an expected call of ProcessBlockTransactions.
+func (mr *MockTransactionServiceInterfaceMockRecorder) ProcessBlockTransactions(ctx, block any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBlockTransactionsByBlockNumber", reflect.TypeOf((*MockTransactionServiceInterface)(nil).ProcessBlockTransactionsByBlockNumber), ctx, number)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBlockTransactions", reflect.TypeOf((*MockTransactionServiceInterface)(nil).ProcessBlockTransactions), ctx, block)
+}
+
+// RetractBlockTransactions mocks base method.
+func (m *MockTransactionServiceInterface) RetractBlockTransactions(number int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetractBlockTransactions", number)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetractBlockTransactions indicates an expected call of RetractBlockTransactions.
+func (mr *MockTransactionServiceInterfaceMockRecorder) RetractBlockTransactions(number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetractBlockTransactions", reflect.TypeOf((*MockTransactionServiceInterface)(nil).RetractBlockTransactions), number)
 }
 
 // MockBlockRpcClient is a mock of BlockRpcClient interface.
@@ -92,6 +107,21 @@ func (mr *MockBlockRpcClientMockRecorder) GetBlockByNumber(ctx, number any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockByNumber", reflect.TypeOf((*MockBlockRpcClient)(nil).GetBlockByNumber), ctx, number)
 }
 
+// GetBlocksByNumber mocks base method.
+func (m *MockBlockRpcClient) GetBlocksByNumber(ctx context.Context, numbers []string) ([]*rpc.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksByNumber", ctx, numbers)
+	ret0, _ := ret[0].([]*rpc.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksByNumber indicates an expected call of GetBlocksByNumber.
+func (mr *MockBlockRpcClientMockRecorder) GetBlocksByNumber(ctx, numbers any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksByNumber", reflect.TypeOf((*MockBlockRpcClient)(nil).GetBlocksByNumber), ctx, numbers)
+}
+
 // MockBlockStorage is a mock of BlockStorage interface.
 type MockBlockStorage struct {
 	ctrl     *gomock.Controller
@@ -115,6 +145,49 @@ func (m *MockBlockStorage) EXPECT() *MockBlockStorageMockRecorder {
 	return m.recorder
 }
 
+// DeleteBlocksBefore mocks base method.
+func (m *MockBlockStorage) DeleteBlocksBefore(number int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlocksBefore", number)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlocksBefore indicates an expected call of DeleteBlocksBefore.
+func (mr *MockBlockStorageMockRecorder) DeleteBlocksBefore(number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlocksBefore", reflect.TypeOf((*MockBlockStorage)(nil).DeleteBlocksBefore), number)
+}
+
+// DeleteBlocksFrom mocks base method.
+func (m *MockBlockStorage) DeleteBlocksFrom(number int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlocksFrom", number)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlocksFrom indicates an expected call of DeleteBlocksFrom.
+func (mr *MockBlockStorageMockRecorder) DeleteBlocksFrom(number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlocksFrom", reflect.TypeOf((*MockBlockStorage)(nil).DeleteBlocksFrom), number)
+}
+
+// GetBlock mocks base method.
+func (m *MockBlockStorage) GetBlock(number int) (*data.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlock", number)
+	ret0, _ := ret[0].(*data.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlock indicates an expected call of GetBlock.
+func (mr *MockBlockStorageMockRecorder) GetBlock(number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlock", reflect.TypeOf((*MockBlockStorage)(nil).GetBlock), number)
+}
+
 // GetCurrentBlockNumber mocks base method.
 func (m *MockBlockStorage) GetCurrentBlockNumber() (int, error) {
 	m.ctrl.T.Helper()
@@ -130,10 +203,26 @@ func (mr *MockBlockStorageMockRecorder) GetCurrentBlockNumber() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentBlockNumber", reflect.TypeOf((*MockBlockStorage)(nil).GetCurrentBlockNumber))
 }
 
+// SaveBlock mocks base method.
+func (m *MockBlockStorage) SaveBlock(block *data.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBlock", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBlock indicates an expected call of SaveBlock.
+func (mr *MockBlockStorageMockRecorder) SaveBlock(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBlock", reflect.TypeOf((*MockBlockStorage)(nil).SaveBlock), block)
+}
+
 // SetCurrentBlockNumber mocks base method.
-func (m *MockBlockStorage) SetCurrentBlockNumber(value int) {
+func (m *MockBlockStorage) SetCurrentBlockNumber(value int) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetCurrentBlockNumber", value)
+	ret := m.ctrl.Call(m, "SetCurrentBlockNumber", value)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // SetCurrentBlockNumber indicates an expected call of SetCurrentBlockNumber.
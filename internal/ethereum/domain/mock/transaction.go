@@ -55,6 +55,53 @@ func (mr *MockAddressServiceInterfaceMockRecorder) IsSubscribed(address any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSubscribed", reflect.TypeOf((*MockAddressServiceInterface)(nil).IsSubscribed), address)
 }
 
+// MockTransactionPublisher is a mock of TransactionPublisher interface.
+type MockTransactionPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionPublisherMockRecorder
+}
+
+// MockTransactionPublisherMockRecorder is the mock recorder for MockTransactionPublisher.
+type MockTransactionPublisherMockRecorder struct {
+	mock *MockTransactionPublisher
+}
+
+// NewMockTransactionPublisher creates a new mock instance.
+func NewMockTransactionPublisher(ctrl *gomock.Controller) *MockTransactionPublisher {
+	mock := &MockTransactionPublisher{ctrl: ctrl}
+	mock.recorder = &MockTransactionPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionPublisher) EXPECT() *MockTransactionPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockTransactionPublisher) Publish(address string, transaction *data.Transaction) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Publish", address, transaction)
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockTransactionPublisherMockRecorder) Publish(address, transaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockTransactionPublisher)(nil).Publish), address, transaction)
+}
+
+// Retract mocks base method.
+func (m *MockTransactionPublisher) Retract(address string, transaction *data.Transaction) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Retract", address, transaction)
+}
+
+// Retract indicates an expected call of Retract.
+func (mr *MockTransactionPublisherMockRecorder) Retract(address, transaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retract", reflect.TypeOf((*MockTransactionPublisher)(nil).Retract), address, transaction)
+}
+
 // MockTransactionStorage is a mock of TransactionStorage interface.
 type MockTransactionStorage struct {
 	ctrl     *gomock.Controller
@@ -78,38 +125,85 @@ func (m *MockTransactionStorage) EXPECT() *MockTransactionStorageMockRecorder {
 	return m.recorder
 }
 
+// DeleteByAddress mocks base method.
+func (m *MockTransactionStorage) DeleteByAddress(address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByAddress", address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByAddress indicates an expected call of DeleteByAddress.
+func (mr *MockTransactionStorageMockRecorder) DeleteByAddress(address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByAddress", reflect.TypeOf((*MockTransactionStorage)(nil).DeleteByAddress), address)
+}
+
+// DeleteByBlockNumber mocks base method.
+func (m *MockTransactionStorage) DeleteByBlockNumber(number int) (map[string][]data.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByBlockNumber", number)
+	ret0, _ := ret[0].(map[string][]data.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByBlockNumber indicates an expected call of DeleteByBlockNumber.
+func (mr *MockTransactionStorageMockRecorder) DeleteByBlockNumber(number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByBlockNumber", reflect.TypeOf((*MockTransactionStorage)(nil).DeleteByBlockNumber), number)
+}
+
 // Exists mocks base method.
-func (m *MockTransactionStorage) Exists(address, hash string) bool {
+func (m *MockTransactionStorage) Exists(address, id string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Exists", address, hash)
+	ret := m.ctrl.Call(m, "Exists", address, id)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // Exists indicates an expected call of Exists.
-func (mr *MockTransactionStorageMockRecorder) Exists(address, hash any) *gomock.Call {
+func (mr *MockTransactionStorageMockRecorder) Exists(address, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockTransactionStorage)(nil).Exists), address, hash)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockTransactionStorage)(nil).Exists), address, id)
 }
 
 // FetchAllByAddress mocks base method.
-func (m *MockTransactionStorage) FetchAllByAddress(address string) []data.Transaction {
+func (m *MockTransactionStorage) FetchAllByAddress(address string, toBlock int) ([]data.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchAllByAddress", address)
+	ret := m.ctrl.Call(m, "FetchAllByAddress", address, toBlock)
 	ret0, _ := ret[0].([]data.Transaction)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // FetchAllByAddress indicates an expected call of FetchAllByAddress.
-func (mr *MockTransactionStorageMockRecorder) FetchAllByAddress(address any) *gomock.Call {
+func (mr *MockTransactionStorageMockRecorder) FetchAllByAddress(address, toBlock any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAllByAddress", reflect.TypeOf((*MockTransactionStorage)(nil).FetchAllByAddress), address)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAllByAddress", reflect.TypeOf((*MockTransactionStorage)(nil).FetchAllByAddress), address, toBlock)
+}
+
+// FindByAddress mocks base method.
+func (m *MockTransactionStorage) FindByAddress(address string, filter data.TransactionFilter) []data.Transaction {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByAddress", address, filter)
+	ret0, _ := ret[0].([]data.Transaction)
+	return ret0
+}
+
+// FindByAddress indicates an expected call of FindByAddress.
+func (mr *MockTransactionStorageMockRecorder) FindByAddress(address, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByAddress", reflect.TypeOf((*MockTransactionStorage)(nil).FindByAddress), address, filter)
 }
 
 // SaveForAddress mocks base method.
-func (m *MockTransactionStorage) SaveForAddress(address string, transaction *data.Transaction) {
+func (m *MockTransactionStorage) SaveForAddress(address string, transaction *data.Transaction) (bool, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SaveForAddress", address, transaction)
+	ret := m.ctrl.Call(m, "SaveForAddress", address, transaction)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // SaveForAddress indicates an expected call of SaveForAddress.
@@ -155,3 +249,78 @@ func (mr *MockTransactionRpcClientMockRecorder) GetBlockByNumber(ctx, number any
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockByNumber", reflect.TypeOf((*MockTransactionRpcClient)(nil).GetBlockByNumber), ctx, number)
 }
+
+// GetBlockReceipts mocks base method.
+func (m *MockTransactionRpcClient) GetBlockReceipts(ctx context.Context, number string) ([]*rpc.Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockReceipts", ctx, number)
+	ret0, _ := ret[0].([]*rpc.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockReceipts indicates an expected call of GetBlockReceipts.
+func (mr *MockTransactionRpcClientMockRecorder) GetBlockReceipts(ctx, number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockReceipts", reflect.TypeOf((*MockTransactionRpcClient)(nil).GetBlockReceipts), ctx, number)
+}
+
+// GetLogs mocks base method.
+func (m *MockTransactionRpcClient) GetLogs(ctx context.Context, filter rpc.LogFilter) ([]*rpc.Log, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLogs", ctx, filter)
+	ret0, _ := ret[0].([]*rpc.Log)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLogs indicates an expected call of GetLogs.
+func (mr *MockTransactionRpcClientMockRecorder) GetLogs(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogs", reflect.TypeOf((*MockTransactionRpcClient)(nil).GetLogs), ctx, filter)
+}
+
+// GetTransactionReceipts mocks base method.
+func (m *MockTransactionRpcClient) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*rpc.Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionReceipts", ctx, hashes)
+	ret0, _ := ret[0].([]*rpc.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionReceipts indicates an expected call of GetTransactionReceipts.
+func (mr *MockTransactionRpcClientMockRecorder) GetTransactionReceipts(ctx, hashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionReceipts", reflect.TypeOf((*MockTransactionRpcClient)(nil).GetTransactionReceipts), ctx, hashes)
+}
+
+// TraceBlock mocks base method.
+func (m *MockTransactionRpcClient) TraceBlock(ctx context.Context, number string) ([]*rpc.Trace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TraceBlock", ctx, number)
+	ret0, _ := ret[0].([]*rpc.Trace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceBlock indicates an expected call of TraceBlock.
+func (mr *MockTransactionRpcClientMockRecorder) TraceBlock(ctx, number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceBlock", reflect.TypeOf((*MockTransactionRpcClient)(nil).TraceBlock), ctx, number)
+}
+
+// TraceBlockByNumber mocks base method.
+func (m *MockTransactionRpcClient) TraceBlockByNumber(ctx context.Context, number string) ([]*rpc.TransactionTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TraceBlockByNumber", ctx, number)
+	ret0, _ := ret[0].([]*rpc.TransactionTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceBlockByNumber indicates an expected call of TraceBlockByNumber.
+func (mr *MockTransactionRpcClientMockRecorder) TraceBlockByNumber(ctx, number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceBlockByNumber", reflect.TypeOf((*MockTransactionRpcClient)(nil).TraceBlockByNumber), ctx, number)
+}
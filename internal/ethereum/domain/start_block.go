@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	StartLatest StartMode = iota
+	StartGenesis
+	StartNumber
+	StartBehindLatest
+)
+
+type (
+	StartMode int
+
+	// StartBlock tells which block is processed first when no block was processed before.
+	StartBlock struct {
+		Mode StartMode
+		// Value is block number for StartNumber and number of blocks back for StartBehindLatest.
+		Value int
+	}
+)
+
+var ErrInvalidStartBlock = errors.New("invalid start block")
+
+// ParseStartBlock parses "latest", "genesis", block number ("123" or "0x7b")
+// or number of blocks back from latest ("latest-100").
+func ParseStartBlock(value string) (StartBlock, error) {
+	switch {
+	case value == "" || value == "latest":
+		return StartBlock{Mode: StartLatest}, nil
+	case value == "genesis":
+		return StartBlock{Mode: StartGenesis}, nil
+	case strings.HasPrefix(value, "latest-"):
+		back, err := strconv.Atoi(strings.TrimPrefix(value, "latest-"))
+		if err != nil || back < 0 {
+			return StartBlock{}, fmt.Errorf("%w: %q", ErrInvalidStartBlock, value)
+		}
+
+		return StartBlock{Mode: StartBehindLatest, Value: back}, nil
+	}
+
+	number, err := strconv.ParseInt(value, 0, 0)
+	if err != nil || number < 0 {
+		return StartBlock{}, fmt.Errorf("%w: %q", ErrInvalidStartBlock, value)
+	}
+
+	return StartBlock{Mode: StartNumber, Value: int(number)}, nil
+}
+
+// Resolve returns number of the first block to process for the given latest block.
+func (s StartBlock) Resolve(latest int) int {
+	switch s.Mode {
+	case StartGenesis:
+		return 0
+	case StartNumber:
+		return s.Value
+	case StartBehindLatest:
+		return max(latest-s.Value, 0)
+	}
+
+	return latest
+}
@@ -0,0 +1,48 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/domain"
+)
+
+func TestParseStartBlock(t *testing.T) {
+	testCases := map[string]struct {
+		value    string
+		expected int
+	}{
+		"empty":         {value: "", expected: 100},
+		"latest":        {value: "latest", expected: 100},
+		"genesis":       {value: "genesis", expected: 0},
+		"number":        {value: "42", expected: 42},
+		"hex number":    {value: "0x2a", expected: 42},
+		"blocks back":   {value: "latest-10", expected: 90},
+		"too much back": {value: "latest-1000", expected: 0},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// act
+			start, err := domain.ParseStartBlock(tc.value)
+
+			// assert
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, start.Resolve(100))
+			}
+		})
+	}
+}
+
+func TestParseStartBlockInvalid(t *testing.T) {
+	for _, value := range []string{"earliest", "-1", "latest-x", "latest--1"} {
+		t.Run(value, func(t *testing.T) {
+			// act
+			_, err := domain.ParseStartBlock(value)
+
+			// assert
+			assert.ErrorIs(t, err, domain.ErrInvalidStartBlock)
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/rpc"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TransferTopic is keccak256 of Transfer(address,address,uint256) event signature,
+	// ERC-20 and ERC-721 share it, but ERC-721 has token id indexed.
+	TransferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// TransferSingleTopic is keccak256 of ERC-1155 TransferSingle(address,address,address,uint256,uint256).
+	TransferSingleTopic = "0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62"
+	// TransferBatchTopic is keccak256 of ERC-1155 TransferBatch(address,address,address,uint256[],uint256[]).
+	TransferBatchTopic = "0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb"
+
+	wordLength = 64
+)
+
+var ErrLogMismatch = errors.New("log does not belong to block")
+
+type tokenTransfer struct {
+	kind      data.TransferKind
+	contract  string
+	from      string
+	to        string
+	amount    string
+	tokenIDs  []string
+	amounts   []string
+	logIndex  int
+	txHash    string
+	blockHash string
+}
+
+// matchTokenTransfers fetches transfer logs of block and returns transfers where matched address
+// is the sender or the receiver. The record keeps fields of the transaction which emitted the log.
+func (t *TransactionService) matchTokenTransfers(ctx context.Context, block *rpc.Block, number int, timestamp int64, match func(address string) bool) ([]addressTransaction, error) {
+	topics := []string{TransferTopic}
+	if t.config.NFTTransfers {
+		topics = append(topics, TransferSingleTopic, TransferBatchTopic)
+	}
+
+	logs, err := t.client.GetLogs(ctx, rpc.LogFilter{
+		BlockHash: block.Hash,
+		Topics:    [][]string{topics},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting transfer logs: %w", err)
+	}
+
+	indexes := make(map[string]int, len(block.Transactions))
+	for index, tx := range block.Transactions {
+		indexes[tx.Hash] = index
+	}
+
+	var matches []addressTransaction
+	for _, log := range logs {
+		if log.Removed {
+			continue
+		}
+
+		transfer, ok, err := decodeTransfer(log)
+		if err != nil {
+			// any contract may emit event with the same signature, malformed one must not stop processing
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number":     number,
+					"transaction_hash": log.TransactionHash,
+					"log_index":        log.LogIndex,
+				}).
+				WithError(err).
+				Warn("Malformed transfer log was skipped")
+
+			continue
+		}
+		if !ok || !t.tracksTransfer(transfer.kind) {
+			continue
+		}
+
+		for _, a := range []string{transfer.from, transfer.to} {
+			if !match(a) {
+				continue
+			}
+
+			index, found := indexes[transfer.txHash]
+			if !found || (transfer.blockHash != "" && block.Hash != "" && transfer.blockHash != block.Hash) {
+				return nil, fmt.Errorf("error matching log %s of transaction %s: %w", log.LogIndex, log.TransactionHash, ErrLogMismatch)
+			}
+
+			transaction, err := newTransaction(block, number, timestamp, index, &block.Transactions[index])
+			if err != nil {
+				return nil, fmt.Errorf("error decoding transaction %s: %w", transfer.txHash, err)
+			}
+			transaction.Kind = transfer.kind
+			transaction.From = transfer.from
+			transaction.To = transfer.to
+			transaction.Value = transfer.amount
+			transaction.TokenContract = transfer.contract
+			transaction.TokenIDs = transfer.tokenIDs
+			transaction.TokenAmounts = transfer.amounts
+			transaction.EventIndex = transfer.logIndex
+
+			if t.transation.Exists(a, transaction.ID()) {
+				continue
+			}
+
+			matches = append(matches, addressTransaction{
+				address:     a,
+				transaction: transaction,
+			})
+		}
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"block_number": number,
+			"logs":         len(logs),
+			"matches":      len(matches),
+		}).
+		Debug("Block token transfers were matched")
+
+	return matches, nil
+}
+
+func (t *TransactionService) tracksTransfer(kind data.TransferKind) bool {
+	if kind == data.TransferKindERC20 {
+		return t.config.TokenTransfers
+	}
+
+	return t.config.NFTTransfers
+}
+
+// decodeTransfer decodes token transfer event by its signature and number of indexed parameters.
+// Logs of other events are reported as not decoded.
+func decodeTransfer(log *rpc.Log) (tokenTransfer, bool, error) {
+	if len(log.Topics) == 0 {
+		return tokenTransfer{}, false, nil
+	}
+
+	var (
+		transfer tokenTransfer
+		err      error
+	)
+	switch topic := strings.ToLower(log.Topics[0]); {
+	case topic == TransferTopic && len(log.Topics) == 3:
+		transfer, err = decodeERC20Transfer(log)
+	case topic == TransferTopic && len(log.Topics) == 4:
+		transfer, err = decodeERC721Transfer(log)
+	case topic == TransferSingleTopic && len(log.Topics) == 4:
+		transfer, err = decodeTransferSingle(log)
+	case topic == TransferBatchTopic && len(log.Topics) == 4:
+		transfer, err = decodeTransferBatch(log)
+	default:
+		return tokenTransfer{}, false, nil
+	}
+	if err != nil {
+		return tokenTransfer{}, false, err
+	}
+
+	logIndex, err := parseHexUint64(log.LogIndex)
+	if err != nil {
+		return tokenTransfer{}, false, fmt.Errorf("error parsing log index: %w", err)
+	}
+
+	transfer.contract = strings.ToLower(log.Address)
+	transfer.logIndex = int(logIndex)
+	transfer.txHash = log.TransactionHash
+	transfer.blockHash = log.BlockHash
+
+	return transfer, true, nil
+}
+
+// decodeERC20Transfer decodes Transfer with sender and receiver indexed and amount in data.
+func decodeERC20Transfer(log *rpc.Log) (tokenTransfer, error) {
+	from, to, err := topicParties(log.Topics[1], log.Topics[2])
+	if err != nil {
+		return tokenTransfer{}, err
+	}
+
+	words, err := dataWords(log.Data)
+	if err != nil || len(words) != 1 {
+		return tokenTransfer{}, fmt.Errorf("error decoding amount: invalid data %q", log.Data)
+	}
+
+	return tokenTransfer{
+		kind:   data.TransferKindERC20,
+		from:   from,
+		to:     to,
+		amount: words[0].quantity(),
+	}, nil
+}
+
+// decodeERC721Transfer decodes Transfer with sender, receiver and token id indexed.
+func decodeERC721Transfer(log *rpc.Log) (tokenTransfer, error) {
+	from, to, err := topicParties(log.Topics[1], log.Topics[2])
+	if err != nil {
+		return tokenTransfer{}, err
+	}
+
+	tokenID, err := topicWord(log.Topics[3])
+	if err != nil {
+		return tokenTransfer{}, fmt.Errorf("error decoding token id: %w", err)
+	}
+
+	return tokenTransfer{
+		kind:     data.TransferKindERC721,
+		from:     from,
+		to:       to,
+		tokenIDs: []string{tokenID.quantity()},
+		amounts:  []string{"0x1"},
+	}, nil
+}
+
+// decodeTransferSingle decodes TransferSingle with operator, sender and receiver indexed, id and value in data.
+func decodeTransferSingle(log *rpc.Log) (tokenTransfer, error) {
+	from, to, err := topicParties(log.Topics[2], log.Topics[3])
+	if err != nil {
+		return tokenTransfer{}, err
+	}
+
+	words, err := dataWords(log.Data)
+	if err != nil || len(words) != 2 {
+		return tokenTransfer{}, fmt.Errorf("error decoding id and value: invalid data %q", log.Data)
+	}
+
+	return tokenTransfer{
+		kind:     data.TransferKindERC1155,
+		from:     from,
+		to:       to,
+		tokenIDs: []string{words[0].quantity()},
+		amounts:  []string{words[1].quantity()},
+	}, nil
+}
+
+// decodeTransferBatch decodes TransferBatch with operator, sender and receiver indexed,
+// ids and values are ABI encoded arrays in data.
+func decodeTransferBatch(log *rpc.Log) (tokenTransfer, error) {
+	from, to, err := topicParties(log.Topics[2], log.Topics[3])
+	if err != nil {
+		return tokenTransfer{}, err
+	}
+
+	words, err := dataWords(log.Data)
+	if err != nil || len(words) < 2 {
+		return tokenTransfer{}, fmt.Errorf("error decoding ids and values: invalid data %q", log.Data)
+	}
+
+	ids, err := wordArray(words, words[0])
+	if err != nil {
+		return tokenTransfer{}, fmt.Errorf("error decoding ids: %w", err)
+	}
+
+	values, err := wordArray(words, words[1])
+	if err != nil {
+		return tokenTransfer{}, fmt.Errorf("error decoding values: %w", err)
+	}
+
+	if len(ids) != len(values) {
+		return tokenTransfer{}, fmt.Errorf("error decoding ids and values: %d ids and %d values", len(ids), len(values))
+	}
+
+	return tokenTransfer{
+		kind:     data.TransferKindERC1155,
+		from:     from,
+		to:       to,
+		tokenIDs: ids,
+		amounts:  values,
+	}, nil
+}
+
+// word is 32 bytes ABI word without 0x prefix.
+type word string
+
+func (w word) quantity() string {
+	value, _ := new(big.Int).SetString(string(w), 16)
+
+	return fmt.Sprintf("0x%x", value)
+}
+
+// int returns word as non-negative int, ok is false when it does not fit.
+func (w word) int() (int, bool) {
+	value, _ := new(big.Int).SetString(string(w), 16)
+	if !value.IsInt64() || value.Int64() > math.MaxInt32 {
+		return 0, false
+	}
+
+	return int(value.Int64()), true
+}
+
+func topicParties(fromTopic, toTopic string) (string, string, error) {
+	from, err := topicAddress(fromTopic)
+	if err != nil {
+		return "", "", fmt.Errorf("error decoding sender: %w", err)
+	}
+
+	to, err := topicAddress(toTopic)
+	if err != nil {
+		return "", "", fmt.Errorf("error decoding receiver: %w", err)
+	}
+
+	return from, to, nil
+}
+
+// topicAddress takes address from 32 bytes topic, where it is left padded with zeros.
+func topicAddress(topic string) (string, error) {
+	w, err := topicWord(topic)
+	if err != nil {
+		return "", err
+	}
+
+	return "0x" + strings.ToLower(string(w[24:])), nil
+}
+
+func topicWord(topic string) (word, error) {
+	words, err := dataWords(topic)
+	if err != nil || len(words) != 1 {
+		return "", fmt.Errorf("invalid topic %q", topic)
+	}
+
+	return words[0], nil
+}
+
+// dataWords splits hex data into 32 bytes words.
+func dataWords(value string) ([]word, error) {
+	value = strings.TrimPrefix(value, "0x")
+	if len(value)%wordLength != 0 {
+		return nil, fmt.Errorf("invalid data length %d", len(value))
+	}
+	if _, ok := new(big.Int).SetString("0"+value, 16); !ok {
+		return nil, fmt.Errorf("invalid hex data")
+	}
+
+	words := make([]word, 0, len(value)/wordLength)
+	for i := 0; i < len(value); i += wordLength {
+		words = append(words, word(value[i:i+wordLength]))
+	}
+
+	return words, nil
+}
+
+// wordArray returns quantities of dynamic array which starts at offset in bytes,
+// the first word of array is its length.
+func wordArray(words []word, offset word) ([]string, error) {
+	start, ok := offset.int()
+	if !ok || start%32 != 0 || start/32 >= len(words) {
+		return nil, fmt.Errorf("invalid array offset 0x%s", offset)
+	}
+	start /= 32
+
+	length, ok := words[start].int()
+	if !ok || length > len(words)-start-1 {
+		return nil, fmt.Errorf("invalid array length 0x%s", words[start])
+	}
+
+	quantities := make([]string, 0, length)
+	for _, w := range words[start+1 : start+1+length] {
+		quantities = append(quantities, w.quantity())
+	}
+
+	return quantities, nil
+}
@@ -0,0 +1,267 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/domain"
+	"trust_walet/internal/ethereum/rpc"
+)
+
+const (
+	tokenSender   = "0x00000000000000000000000000000000000000aa"
+	tokenReceiver = "0x00000000000000000000000000000000000000bb"
+)
+
+func tokenTestBlock() *rpc.Block {
+	return &rpc.Block{
+		Number: "0xa",
+		Hash:   "block_hash",
+		Transactions: []rpc.Transaction{
+			{Hash: "other", From: "addr1", To: "addr2", GasPrice: "0x2"},
+			{Hash: "tx", From: tokenSender, To: "0xtoken", GasPrice: "0x2", Nonce: "0x7"},
+		},
+	}
+}
+
+func transferLog(topics ...string) *rpc.Log {
+	return &rpc.Log{
+		Address:         "0xTOKEN",
+		Topics:          append([]string{domain.TransferTopic}, topics...),
+		Data:            "0x00000000000000000000000000000000000000000000000000000000000003e8",
+		BlockHash:       "block_hash",
+		TransactionHash: "tx",
+		LogIndex:        "0x4",
+	}
+}
+
+func addressTopic(address string) string {
+	return "0x000000000000000000000000" + address[2:]
+}
+
+func TestTransactionServiceProcessBlockTransactionsTokenTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{Receipts: true, TokenTransfers: true})
+
+	var saved *data.Transaction
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq(tokenReceiver)).Return(true)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().GetLogs(gomock.Any(), gomock.Eq(rpc.LogFilter{
+		BlockHash: "block_hash",
+		Topics:    [][]string{{domain.TransferTopic}},
+	})).Return([]*rpc.Log{
+		transferLog(addressTopic(tokenSender), addressTopic(tokenReceiver)),
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq(tokenReceiver), gomock.Eq("tx:erc20:4")).Return(false)
+	tc.mockClient.EXPECT().GetBlockReceipts(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.Receipt{
+		{TransactionHash: "tx", BlockHash: "block_hash", Status: "0x1", GasUsed: "0x5208", EffectiveGasPrice: "0x2"},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq(tokenReceiver), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved = tx
+		})
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), tokenTestBlock())
+
+	// assert
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, data.TransferKindERC20, saved.Kind)
+		assert.Equal(t, "tx", saved.Hash)
+		assert.Equal(t, 1, saved.TransactionIndex)
+		assert.Equal(t, uint64(7), saved.Nonce)
+		assert.Equal(t, tokenSender, saved.From)
+		assert.Equal(t, tokenReceiver, saved.To)
+		assert.Equal(t, "0x3e8", saved.Value)
+		assert.Equal(t, "0xtoken", saved.TokenContract)
+		assert.Equal(t, 4, saved.EventIndex)
+		assert.Equal(t, data.TransactionStatusSuccess, saved.Status)
+		assert.Empty(t, saved.Fee)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsTokenTransferSkipsOtherLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{TokenTransfers: true})
+
+	// ERC-721 transfer has token id indexed as well
+	nft := transferLog(addressTopic(tokenSender), addressTopic(tokenReceiver), "0x1")
+	removed := transferLog(addressTopic(tokenSender), addressTopic(tokenReceiver))
+	removed.Removed = true
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return([]*rpc.Log{nft, removed}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), tokenTestBlock())
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsTokenTransferOfOtherBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{TokenTransfers: true})
+
+	log := transferLog(addressTopic(tokenSender), addressTopic(tokenReceiver))
+	log.TransactionHash = "unknown"
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq(tokenReceiver)).Return(true)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return([]*rpc.Log{log}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), tokenTestBlock())
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrLogMismatch)
+}
+
+func nftLog(topic string, data string, topics ...string) *rpc.Log {
+	return &rpc.Log{
+		Address:         "0xnft",
+		Topics:          append([]string{topic}, topics...),
+		Data:            data,
+		BlockHash:       "block_hash",
+		TransactionHash: "tx",
+		LogIndex:        "0x1",
+	}
+}
+
+func processNFTLog(t *testing.T, log *rpc.Log) *data.Transaction {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{NFTTransfers: true})
+
+	var saved *data.Transaction
+
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq(tokenReceiver)).Return(true).MaxTimes(1)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockClient.EXPECT().GetLogs(gomock.Any(), gomock.Eq(rpc.LogFilter{
+		BlockHash: "block_hash",
+		Topics:    [][]string{{domain.TransferTopic, domain.TransferSingleTopic, domain.TransferBatchTopic}},
+	})).Return([]*rpc.Log{log}, nil)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq(tokenReceiver), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved = tx
+		}).
+		MaxTimes(1)
+
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), tokenTestBlock())
+	assert.NoError(t, err)
+
+	return saved
+}
+
+func TestTransactionServiceProcessBlockTransactionsERC721Transfer(t *testing.T) {
+	// arrange
+	log := nftLog(domain.TransferTopic, "0x",
+		addressTopic(tokenSender),
+		addressTopic(tokenReceiver),
+		"0x000000000000000000000000000000000000000000000000000000000000002a",
+	)
+
+	// act
+	saved := processNFTLog(t, log)
+
+	// assert
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, data.TransferKindERC721, saved.Kind)
+		assert.Equal(t, "0xnft", saved.TokenContract)
+		assert.Equal(t, tokenSender, saved.From)
+		assert.Equal(t, tokenReceiver, saved.To)
+		assert.Empty(t, saved.Value)
+		assert.Equal(t, []string{"0x2a"}, saved.TokenIDs)
+		assert.Equal(t, []string{"0x1"}, saved.TokenAmounts)
+		assert.Equal(t, "tx:erc721:1", saved.ID())
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsERC1155TransferSingle(t *testing.T) {
+	// arrange
+	log := nftLog(domain.TransferSingleTopic,
+		"0x0000000000000000000000000000000000000000000000000000000000000007"+
+			"0000000000000000000000000000000000000000000000000000000000000003",
+		addressTopic("0x00000000000000000000000000000000000000cc"),
+		addressTopic(tokenSender),
+		addressTopic(tokenReceiver),
+	)
+
+	// act
+	saved := processNFTLog(t, log)
+
+	// assert
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, data.TransferKindERC1155, saved.Kind)
+		assert.Equal(t, tokenSender, saved.From)
+		assert.Equal(t, tokenReceiver, saved.To)
+		assert.Equal(t, []string{"0x7"}, saved.TokenIDs)
+		assert.Equal(t, []string{"0x3"}, saved.TokenAmounts)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsERC1155TransferBatch(t *testing.T) {
+	// arrange
+	log := nftLog(domain.TransferBatchTopic,
+		"0x0000000000000000000000000000000000000000000000000000000000000040"+
+			"00000000000000000000000000000000000000000000000000000000000000a0"+
+			"0000000000000000000000000000000000000000000000000000000000000002"+
+			"0000000000000000000000000000000000000000000000000000000000000001"+
+			"0000000000000000000000000000000000000000000000000000000000000002"+
+			"0000000000000000000000000000000000000000000000000000000000000002"+
+			"000000000000000000000000000000000000000000000000000000000000000a"+
+			"0000000000000000000000000000000000000000000000000000000000000014",
+		addressTopic("0x00000000000000000000000000000000000000cc"),
+		addressTopic(tokenSender),
+		addressTopic(tokenReceiver),
+	)
+
+	// act
+	saved := processNFTLog(t, log)
+
+	// assert
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, data.TransferKindERC1155, saved.Kind)
+		assert.Equal(t, []string{"0x1", "0x2"}, saved.TokenIDs)
+		assert.Equal(t, []string{"0xa", "0x14"}, saved.TokenAmounts)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsMalformedTransferBatch(t *testing.T) {
+	// arrange
+	log := nftLog(domain.TransferBatchTopic,
+		"0x0000000000000000000000000000000000000000000000000000000000000040"+
+			"0000000000000000000000000000000000000000000000000000000000000fff",
+		addressTopic("0x00000000000000000000000000000000000000cc"),
+		addressTopic(tokenSender),
+		addressTopic(tokenReceiver),
+	)
+
+	// act
+	saved := processNFTLog(t, log)
+
+	// assert
+	assert.Nil(t, saved)
+}
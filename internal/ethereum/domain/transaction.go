@@ -2,7 +2,15 @@ package domain
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
 
 	"trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/rpc"
@@ -15,43 +23,153 @@ type (
 		IsSubscribed(address string) bool
 	}
 
+	TransactionPublisher interface {
+		Publish(address string, transaction *data.Transaction)
+		Retract(address string, transaction *data.Transaction)
+	}
+
 	TransactionStorage interface {
-		SaveForAddress(address string, transaction *data.Transaction)
-		Exists(address, hash string) bool
-		FetchAllByAddress(address string) []data.Transaction
+		// SaveForAddress returns false when transfer is already saved for address.
+		SaveForAddress(address string, transaction *data.Transaction) (bool, error)
+		Exists(address, id string) bool
+		FetchAllByAddress(address string, toBlock int) ([]data.Transaction, error)
+		FindByAddress(address string, filter data.TransactionFilter) []data.Transaction
+		// DeleteByBlockNumber returns removed transactions by address.
+		DeleteByBlockNumber(number int) (map[string][]data.Transaction, error)
+		DeleteByAddress(address string) error
 	}
 
 	TransactionRpcClient interface {
 		GetBlockByNumber(ctx context.Context, number string) (*rpc.Block, error)
+		GetBlockReceipts(ctx context.Context, number string) ([]*rpc.Receipt, error)
+		GetTransactionReceipts(ctx context.Context, hashes []string) ([]*rpc.Receipt, error)
+		GetLogs(ctx context.Context, filter rpc.LogFilter) ([]*rpc.Log, error)
+		TraceBlockByNumber(ctx context.Context, number string) ([]*rpc.TransactionTrace, error)
+		TraceBlock(ctx context.Context, number string) ([]*rpc.Trace, error)
+	}
+
+	TransactionServiceConfig struct {
+		// Confirmations is the number of blocks which must be built on top of
+		// transaction block before transaction is delivered as confirmed.
+		Confirmations int
+		// Receipts enables enrichment of saved transactions with status, gas used and fee from receipts.
+		// Receipts of contract creations are always fetched for created contract address.
+		Receipts bool
+		// DropFailed skips reverted transactions, it takes effect only with Receipts.
+		DropFailed bool
+		// TokenTransfers enables tracking of ERC-20 transfers from and to subscribed addresses.
+		TokenTransfers bool
+		// NFTTransfers enables tracking of ERC-721 and ERC-1155 transfers from and to subscribed addresses.
+		NFTTransfers bool
+		// InternalTransfers enables tracking of ETH sent by contract calls, node must provide
+		// debug_traceBlockByNumber or trace_block.
+		InternalTransfers bool
 	}
 
 	TransactionService struct {
 		client     TransactionRpcClient
 		address    AddressServiceInterface
 		transation TransactionStorage
+		events     TransactionPublisher
+		config     TransactionServiceConfig
+	}
+
+	addressTransaction struct {
+		address     string
+		transaction *data.Transaction
 	}
 )
 
+const defaultQueryLimit = 100
+
+var (
+	ErrInvalidCursor   = errors.New("invalid transaction cursor")
+	ErrReceiptMismatch = errors.New("receipt does not belong to block")
+)
+
 func NewTransactionService(
 	client TransactionRpcClient,
 	address AddressServiceInterface,
 	transation TransactionStorage,
+	events TransactionPublisher,
+	config TransactionServiceConfig,
 ) *TransactionService {
 	return &TransactionService{
 		client:     client,
 		address:    address,
 		transation: transation,
+		events:     events,
+		config:     config,
 	}
 }
 
-func (t *TransactionService) FetchAllByAddress(addr string) []data.Transaction {
+// FetchConfirmedByAddress returns once transactions of address which have enough confirmations at head block.
+func (t *TransactionService) FetchConfirmedByAddress(addr string, head int) ([]data.Transaction, error) {
+	addr = data.NormalizeAddress(addr)
+
 	logrus.
 		WithFields(logrus.Fields{
-			"address": addr,
+			"address":    addr,
+			"head_block": head,
 		}).
-		Info("Fetch once transactions for address")
+		Info("Fetch once confirmed transactions for address")
+
+	transactions, err := t.transation.FetchAllByAddress(addr, head-t.config.Confirmations)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching confirmed transactions of %s: %w", addr, err)
+	}
+
+	return transactions, nil
+}
+
+// FetchPendingByAddress returns transactions of address which are still waiting for confirmations at head block.
+func (t *TransactionService) FetchPendingByAddress(addr string, head int) []data.Transaction {
+	return t.transation.FindByAddress(data.NormalizeAddress(addr), data.TransactionFilter{
+		FromBlock: head - t.config.Confirmations + 1,
+		ToBlock:   math.MaxInt,
+	})
+}
+
+// QueryByAddress returns a page of confirmed transactions of address without removing them from storage.
+func (t *TransactionService) QueryByAddress(addr string, head int, query data.TransactionQuery) (data.TransactionPage, error) {
+	filter := data.TransactionFilter{
+		FromBlock: query.FromBlock,
+		ToBlock:   head - t.config.Confirmations,
+		Direction: query.Direction,
+		Limit:     query.Limit,
+		Kinds:     query.Kinds,
+	}
+	if query.ToBlock > 0 && query.ToBlock < filter.ToBlock {
+		filter.ToBlock = query.ToBlock
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultQueryLimit
+	}
+
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return data.TransactionPage{}, err
+		}
 
-	return t.transation.FetchAllByAddress(addr)
+		filter.After = &after
+	}
+
+	// one extra transaction tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+
+	transactions := t.transation.FindByAddress(data.NormalizeAddress(addr), filter)
+
+	page := data.TransactionPage{
+		Transactions: transactions,
+	}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = encodeCursor(page.Transactions[limit-1].Position())
+	}
+
+	return page, nil
 }
 
 func (t *TransactionService) ProcessBlockTransactionsByBlockNumber(ctx context.Context, number int) error {
@@ -67,29 +185,180 @@ func (t *TransactionService) ProcessBlockTransactionsByBlockNumber(ctx context.C
 		return fmt.Errorf("error getting block %d for processing: %w", number, err)
 	}
 
-	for _, tx := range block.Transactions {
-		txAddresses := []string{tx.From, tx.To}
+	return t.ProcessBlockTransactions(ctx, block)
+}
+
+func (t *TransactionService) ProcessBlockTransactions(ctx context.Context, block *rpc.Block) error {
+	return t.processBlock(ctx, block, t.address.IsSubscribed)
+}
+
+// ProcessBlockTransactionsForAddress saves transactions of block only for the given address,
+// it is used to scan history of a single address. Transactions already saved are skipped.
+func (t *TransactionService) ProcessBlockTransactionsForAddress(ctx context.Context, block *rpc.Block, addr string) error {
+	addr = data.NormalizeAddress(addr)
+
+	return t.processBlock(ctx, block, func(a string) bool {
+		return a != "" && data.NormalizeAddress(a) == addr
+	})
+}
+
+// processBlock saves transactions of block for addresses accepted by match.
+func (t *TransactionService) processBlock(ctx context.Context, block *rpc.Block, match func(address string) bool) error {
+	number, err := parseHexNumber(block.Number)
+	if err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"block_number": block.Number,
+			}).
+			WithError(err).
+			Error("failed to parse block number")
+
+		return fmt.Errorf("error parsing block number %s: %w", block.Number, err)
+	}
+
+	timestamp, err := parseHexUint64(block.Timestamp)
+	if err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"block_number": number,
+				"timestamp":    block.Timestamp,
+			}).
+			WithError(err).
+			Error("failed to parse block timestamp")
+
+		return fmt.Errorf("error parsing timestamp of block %d: %w", number, err)
+	}
+
+	// matches are collected first, so receipts are fetched only for blocks with subscribed addresses
+	var matches []addressTransaction
+	for index, tx := range block.Transactions {
+		txAddresses := []string{data.NormalizeAddress(tx.From), data.NormalizeAddress(tx.To)}
 
 		for _, a := range txAddresses {
-			if t.address.IsSubscribed(a) && !t.transation.Exists(a, tx.Hash) {
-				t.transation.SaveForAddress(a, &data.Transaction{
-					Hash:  tx.Hash,
-					From:  tx.From,
-					To:    tx.To,
-					Value: tx.Value,
-				})
+			// contract creation has no receiver
+			if a == "" {
+				continue
+			}
 
-				logrus.
-					WithFields(logrus.Fields{
-						"block_number":     number,
-						"address":          a,
-						"transaction_hash": tx.Hash,
-					}).
-					Info("Block transaction was saved for address")
+			if match(a) && !t.transation.Exists(a, tx.Hash) {
+				transaction, err := newTransaction(block, number, int64(timestamp), index, &tx)
+				if err != nil {
+					logrus.
+						WithFields(logrus.Fields{
+							"block_number":     number,
+							"transaction_hash": tx.Hash,
+						}).
+						WithError(err).
+						Error("failed to decode transaction")
+
+					return fmt.Errorf("error decoding transaction %s of block %d: %w", tx.Hash, number, err)
+				}
+
+				matches = append(matches, addressTransaction{
+					address:     a,
+					transaction: transaction,
+				})
 			}
 		}
 	}
 
+	if t.config.TokenTransfers || t.config.NFTTransfers {
+		transfers, err := t.matchTokenTransfers(ctx, block, number, int64(timestamp), match)
+		if err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number": number,
+				}).
+				WithError(err).
+				Error("failed to match token transfers")
+
+			return fmt.Errorf("error matching token transfers of block %d: %w", number, err)
+		}
+
+		matches = append(matches, transfers...)
+	}
+
+	if t.config.InternalTransfers {
+		transfers, err := t.matchInternalTransfers(ctx, block, number, int64(timestamp), match)
+		if err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number": number,
+				}).
+				WithError(err).
+				Error("failed to match internal transfers")
+
+			return fmt.Errorf("error matching internal transfers of block %d: %w", number, err)
+		}
+
+		matches = append(matches, transfers...)
+	}
+
+	matches = uniqueMatches(matches)
+
+	// created contract address is known only from receipt
+	receiptMatches := matches
+	if !t.config.Receipts {
+		receiptMatches = slices.DeleteFunc(slices.Clone(matches), func(m addressTransaction) bool {
+			return m.transaction.Kind != data.TransferKindDeployment
+		})
+	}
+
+	if len(receiptMatches) > 0 {
+		if err := t.applyReceipts(ctx, block, receiptMatches); err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number": number,
+				}).
+				WithError(err).
+				Error("failed to apply transaction receipts")
+
+			return fmt.Errorf("error applying receipts of block %d: %w", number, err)
+		}
+	}
+
+	for _, m := range matches {
+		if t.config.DropFailed && m.transaction.Status == data.TransactionStatusFailed {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number":     number,
+					"address":          m.address,
+					"transaction_hash": m.transaction.Hash,
+				}).
+				Info("Failed block transaction was dropped")
+
+			continue
+		}
+
+		// the same block may be processed by backfill and live processing at once,
+		// event is sent only by the one which saved the transfer
+		saved, err := t.transation.SaveForAddress(m.address, m.transaction)
+		if err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number":     number,
+					"address":          m.address,
+					"transaction_hash": m.transaction.Hash,
+				}).
+				WithError(err).
+				Error("failed to save block transaction")
+
+			return fmt.Errorf("error saving transaction %s of block %d: %w", m.transaction.Hash, number, err)
+		}
+		if !saved {
+			continue
+		}
+		t.events.Publish(m.address, m.transaction)
+
+		logrus.
+			WithFields(logrus.Fields{
+				"block_number":     number,
+				"address":          m.address,
+				"transaction_hash": m.transaction.Hash,
+			}).
+			Info("Block transaction was saved for address")
+	}
+
 	logrus.
 		WithFields(logrus.Fields{
 			"block_number": number,
@@ -98,3 +367,246 @@ func (t *TransactionService) ProcessBlockTransactionsByBlockNumber(ctx context.C
 
 	return nil
 }
+
+// uniqueMatches drops repeated transfers of the same address. Self-transfer, ERC-20 log or
+// call with the same sender and receiver is matched twice, as Exists is checked before saving.
+func uniqueMatches(matches []addressTransaction) []addressTransaction {
+	type key struct {
+		address string
+		id      string
+	}
+
+	seen := make(map[key]struct{}, len(matches))
+
+	return slices.DeleteFunc(matches, func(m addressTransaction) bool {
+		k := key{address: m.address, id: m.transaction.ID()}
+		if _, ok := seen[k]; ok {
+			return true
+		}
+		seen[k] = struct{}{}
+
+		return false
+	})
+}
+
+// RemoveByAddress removes every transaction of address which is no longer tracked.
+func (t *TransactionService) RemoveByAddress(addr string) error {
+	addr = data.NormalizeAddress(addr)
+
+	if err := t.transation.DeleteByAddress(addr); err != nil {
+		return fmt.Errorf("error removing transactions of %s: %w", addr, err)
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"address": addr,
+		}).
+		Info("Address transactions were removed")
+
+	return nil
+}
+
+// RetractBlockTransactions removes transactions saved for a block which is no longer canonical,
+// subscribers get retracted event of every removed one.
+func (t *TransactionService) RetractBlockTransactions(number int) error {
+	removed, err := t.transation.DeleteByBlockNumber(number)
+	if err != nil {
+		return fmt.Errorf("error retracting transactions of block %d: %w", number, err)
+	}
+
+	for _, address := range slices.Sorted(maps.Keys(removed)) {
+		for _, transaction := range removed[address] {
+			t.events.Retract(address, &transaction)
+		}
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"block_number": number,
+			"addresses":    len(removed),
+		}).
+		Warn("Block transactions were retracted")
+
+	return nil
+}
+
+func encodeCursor(position data.TransactionPosition) string {
+	value := fmt.Sprintf("%d:%d:%s:%d", position.BlockNumber, position.TransactionIndex, position.Kind, position.EventIndex)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func decodeCursor(cursor string) (data.TransactionPosition, error) {
+	var position data.TransactionPosition
+
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return position, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	// cursor of native transfer may be issued before transfer kinds were added
+	parts := strings.Split(string(value), ":")
+	if len(parts) != 2 && len(parts) != 4 {
+		return position, fmt.Errorf("%w: unexpected number of parts %d", ErrInvalidCursor, len(parts))
+	}
+
+	if position.BlockNumber, err = strconv.Atoi(parts[0]); err != nil {
+		return position, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if position.TransactionIndex, err = strconv.Atoi(parts[1]); err != nil {
+		return position, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if len(parts) == 4 {
+		position.Kind = data.TransferKind(parts[2])
+		if position.EventIndex, err = strconv.Atoi(parts[3]); err != nil {
+			return position, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+	}
+
+	return position, nil
+}
+
+// applyReceipts fetches receipts of block and copies execution result to matched transactions,
+// or only created contract address when Receipts is disabled.
+func (t *TransactionService) applyReceipts(ctx context.Context, block *rpc.Block, matches []addressTransaction) error {
+	receipts, err := t.client.GetBlockReceipts(ctx, block.Number)
+	if rpc.IsMethodNotFound(err) {
+		hashes := make([]string, 0, len(matches))
+		for _, m := range matches {
+			if !slices.Contains(hashes, m.transaction.Hash) {
+				hashes = append(hashes, m.transaction.Hash)
+			}
+		}
+
+		receipts, err = t.client.GetTransactionReceipts(ctx, hashes)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting receipts: %w", err)
+	}
+
+	byHash := make(map[string]*rpc.Receipt, len(receipts))
+	for _, receipt := range receipts {
+		byHash[receipt.TransactionHash] = receipt
+	}
+
+	for _, m := range matches {
+		receipt, ok := byHash[m.transaction.Hash]
+		if !ok {
+			return fmt.Errorf("error getting receipt of transaction %s: %w", m.transaction.Hash, rpc.ErrReceiptNotFound)
+		}
+
+		// receipt of another block means the chain was reorganized meanwhile
+		if receipt.BlockHash != "" && block.Hash != "" && receipt.BlockHash != block.Hash {
+			return fmt.Errorf("error applying receipt of transaction %s: %w", m.transaction.Hash, ErrReceiptMismatch)
+		}
+
+		// without Receipts only deployments are fetched and only for created contract address,
+		// so their status is unknown and DropFailed does not drop them
+		if !t.config.Receipts {
+			m.transaction.ContractAddress = receipt.ContractAddress
+			continue
+		}
+
+		if err := applyReceipt(m.transaction, receipt); err != nil {
+			return fmt.Errorf("error applying receipt of transaction %s: %w", m.transaction.Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// applyReceipt sets execution result, fee is gas used multiplied by effective gas price,
+// which is missing in receipts before London fork, so gas price of transaction is used instead.
+func applyReceipt(transaction *data.Transaction, receipt *rpc.Receipt) error {
+	gasUsed, err := parseHexUint64(receipt.GasUsed)
+	if err != nil {
+		return fmt.Errorf("error parsing gas used: %w", err)
+	}
+
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == "" {
+		gasPrice = transaction.GasPrice
+	}
+
+	price, ok := new(big.Int).SetString(strings.TrimPrefix(gasPrice, "0x"), 16)
+	if !ok {
+		return fmt.Errorf("error parsing effective gas price %q", gasPrice)
+	}
+
+	switch receipt.Status {
+	case rpc.ReceiptStatusSuccess:
+		transaction.Status = data.TransactionStatusSuccess
+	case rpc.ReceiptStatusFailed:
+		transaction.Status = data.TransactionStatusFailed
+	default:
+		transaction.Status = data.TransactionStatusUnknown
+	}
+	transaction.GasUsed = gasUsed
+	transaction.EffectiveGasPrice = gasPrice
+	transaction.ContractAddress = receipt.ContractAddress
+
+	// fee is paid once per transaction, so it is not repeated on transfers inside it
+	if transaction.Kind.IsTransaction() {
+		transaction.Fee = fmt.Sprintf("0x%x", price.Mul(price, new(big.Int).SetUint64(gasUsed)))
+	}
+
+	return nil
+}
+
+// newTransaction converts transaction of block into stored model, block context is taken from the block itself.
+// Addresses are normalised, so they match subscribed addresses and storage keys.
+func newTransaction(block *rpc.Block, number int, timestamp int64, index int, tx *rpc.Transaction) (*data.Transaction, error) {
+	nonce, err := parseHexUint64(tx.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing nonce: %w", err)
+	}
+
+	gas, err := parseHexUint64(tx.Gas)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing gas: %w", err)
+	}
+
+	txType, err := parseHexUint64(tx.Type)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing type: %w", err)
+	}
+
+	chainID, err := parseHexUint64(tx.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing chain id: %w", err)
+	}
+
+	kind := data.TransferKindNative
+	if tx.To == "" {
+		kind = data.TransferKindDeployment
+	}
+
+	return &data.Transaction{
+		Kind:                 kind,
+		BlockNumber:          number,
+		BlockHash:            block.Hash,
+		Timestamp:            timestamp,
+		TransactionIndex:     index,
+		Hash:                 tx.Hash,
+		From:                 data.NormalizeAddress(tx.From),
+		To:                   data.NormalizeAddress(tx.To),
+		Value:                tx.Value,
+		Nonce:                nonce,
+		Gas:                  gas,
+		GasPrice:             tx.GasPrice,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		Type:                 int(txType),
+		ChainID:              int64(chainID),
+		Input:                tx.Input,
+	}, nil
+}
+
+// parseHexUint64 parses hex quantity, missing value is zero as legacy transactions have no type or chain id.
+func parseHexUint64(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+}
@@ -3,11 +3,13 @@ package domain_test
 import (
 	"context"
 	"errors"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/domain"
 	mockDomain "trust_walet/internal/ethereum/domain/mock"
 	"trust_walet/internal/ethereum/rpc"
@@ -17,20 +19,30 @@ type unitTransactionService struct {
 	mockClient             *mockDomain.MockTransactionRpcClient
 	mockAddressService     *mockDomain.MockAddressServiceInterface
 	mockTransactionStorage *mockDomain.MockTransactionStorage
+	mockPublisher          *mockDomain.MockTransactionPublisher
 	transactionService     *domain.TransactionService
 }
 
 func newUnitTransactionService(ctrl *gomock.Controller) *unitTransactionService {
+	return newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{
+		Confirmations: 2,
+	})
+}
+
+func newUnitTransactionServiceWithConfig(ctrl *gomock.Controller, config domain.TransactionServiceConfig) *unitTransactionService {
 	unit := unitTransactionService{
 		mockClient:             mockDomain.NewMockTransactionRpcClient(ctrl),
 		mockAddressService:     mockDomain.NewMockAddressServiceInterface(ctrl),
 		mockTransactionStorage: mockDomain.NewMockTransactionStorage(ctrl),
+		mockPublisher:          mockDomain.NewMockTransactionPublisher(ctrl),
 	}
 
 	unit.transactionService = domain.NewTransactionService(
 		unit.mockClient,
 		unit.mockAddressService,
 		unit.mockTransactionStorage,
+		unit.mockPublisher,
+		config,
 	)
 
 	return &unit
@@ -159,3 +171,622 @@ func TestTransactionServiceProcessBlockTransactionsByBlockNumberClientFailed(t *
 	// assert
 	assert.Error(t, err)
 }
+
+func TestTransactionServiceProcessBlockTransactionsSetsBlockNumber(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	block := rpc.Block{
+		Number: "0xa",
+		Transactions: []rpc.Transaction{
+			{
+				Hash: "hash",
+				From: "addr1",
+				To:   "addr2",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Eq("hash")).Return(false)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			assert.Equal(t, 10, tx.BlockNumber)
+		})
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr2")).Return(false)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsFullModel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	block := rpc.Block{
+		Number:    "0xa",
+		Hash:      "block_hash",
+		Timestamp: "0x66e88f3b",
+		Transactions: []rpc.Transaction{
+			{
+				Hash: "other",
+			},
+			{
+				Hash:                 "hash",
+				From:                 "addr1",
+				To:                   "addr2",
+				Value:                "0x1b4fbd92b5f8000",
+				Nonce:                "0x71c",
+				Gas:                  "0x6a021",
+				GasPrice:             "0x18b0ee9f5",
+				MaxFeePerGas:         "0x2b1a06a7e",
+				MaxPriorityFeePerGas: "0x3747177",
+				Type:                 "0x2",
+				ChainID:              "0x1",
+				Input:                "0x0162e2d0",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Eq("hash")).Return(false)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Eq(&data.Transaction{
+		BlockNumber:          10,
+		BlockHash:            "block_hash",
+		Timestamp:            0x66e88f3b,
+		TransactionIndex:     1,
+		Hash:                 "hash",
+		From:                 "addr1",
+		To:                   "addr2",
+		Value:                "0x1b4fbd92b5f8000",
+		Nonce:                0x71c,
+		Gas:                  0x6a021,
+		GasPrice:             "0x18b0ee9f5",
+		MaxFeePerGas:         "0x2b1a06a7e",
+		MaxPriorityFeePerGas: "0x3747177",
+		Type:                 2,
+		ChainID:              1,
+		Input:                "0x0162e2d0",
+	}))
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsMalformedTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	block := rpc.Block{
+		Number: "0xa",
+		Transactions: []rpc.Transaction{
+			{
+				Hash:  "hash",
+				From:  "addr1",
+				Nonce: "0xzz",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Eq("hash")).Return(false)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.Error(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsContractCreation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	block := rpc.Block{
+		Number: "0xa",
+		Hash:   "block_hash",
+		Transactions: []rpc.Transaction{
+			{Hash: "other", From: "addr2"},
+			{Hash: "hash", From: "deployer", GasPrice: "0x2"},
+		},
+	}
+
+	var saved *data.Transaction
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr2")).Return(false)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("deployer")).Return(true)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("deployer"), gomock.Eq("hash")).Return(false)
+	tc.mockClient.EXPECT().GetBlockReceipts(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.Receipt{
+		{TransactionHash: "other", BlockHash: "block_hash", Status: "0x1", GasUsed: "0x1", ContractAddress: "another"},
+		{TransactionHash: "hash", BlockHash: "block_hash", Status: "0x1", GasUsed: "0x1", ContractAddress: "contract"},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("deployer"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved = tx
+		})
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, data.TransferKindDeployment, saved.Kind)
+		assert.Equal(t, "hash", saved.ID())
+		assert.Empty(t, saved.To)
+		assert.Equal(t, "contract", saved.ContractAddress)
+		assert.Empty(t, saved.Status)
+		assert.Empty(t, saved.Fee)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsFailedContractCreationWithoutReceipts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{DropFailed: true})
+
+	block := rpc.Block{
+		Number: "0xa",
+		Hash:   "block_hash",
+		Transactions: []rpc.Transaction{
+			{Hash: "hash", From: "deployer", GasPrice: "0x2"},
+		},
+	}
+
+	var saved *data.Transaction
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("deployer")).Return(true)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("deployer"), gomock.Eq("hash")).Return(false)
+	tc.mockClient.EXPECT().GetBlockReceipts(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.Receipt{
+		{TransactionHash: "hash", BlockHash: "block_hash", Status: "0x0", GasUsed: "0x1", ContractAddress: "contract"},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("deployer"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved = tx
+		})
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, "contract", saved.ContractAddress)
+		assert.Empty(t, saved.Status)
+		assert.Zero(t, saved.GasUsed)
+		assert.Empty(t, saved.Fee)
+	}
+}
+
+func receiptsTestBlock() *rpc.Block {
+	return &rpc.Block{
+		Number: "0xa",
+		Hash:   "block_hash",
+		Transactions: []rpc.Transaction{
+			{Hash: "success", From: "addr1", To: "addr2", GasPrice: "0x2"},
+			{Hash: "failed", From: "addr1", To: "addr3", GasPrice: "0x2"},
+		},
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsReceipts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{Receipts: true})
+
+	saved := make(map[string]*data.Transaction)
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true).Times(2)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Any()).Return(false).Times(2)
+	tc.mockClient.EXPECT().GetBlockReceipts(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.Receipt{
+		{TransactionHash: "success", BlockHash: "block_hash", Status: "0x1", GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00"},
+		{TransactionHash: "failed", BlockHash: "block_hash", Status: "0x0", GasUsed: "0x100", ContractAddress: "contract"},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			saved[tx.Hash] = tx
+		}).
+		Times(2)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), receiptsTestBlock())
+
+	// assert
+	assert.NoError(t, err)
+	if assert.Contains(t, saved, "success") {
+		assert.Equal(t, data.TransactionStatusSuccess, saved["success"].Status)
+		assert.Equal(t, uint64(21000), saved["success"].GasUsed)
+		assert.Equal(t, "0x3b9aca00", saved["success"].EffectiveGasPrice)
+		assert.Equal(t, "0x1319718a5000", saved["success"].Fee)
+	}
+	if assert.Contains(t, saved, "failed") {
+		assert.Equal(t, data.TransactionStatusFailed, saved["failed"].Status)
+		assert.Equal(t, "0x2", saved["failed"].EffectiveGasPrice)
+		assert.Equal(t, "0x200", saved["failed"].Fee)
+		assert.Equal(t, "contract", saved["failed"].ContractAddress)
+	}
+}
+
+func TestTransactionServiceProcessBlockTransactionsDropFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{Receipts: true, DropFailed: true})
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true).Times(2)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Any()).Return(false).Times(2)
+	tc.mockClient.EXPECT().GetBlockReceipts(gomock.Any(), gomock.Eq("0xa")).
+		Return(nil, &rpc.Error{Code: rpc.CodeMethodNotFound, Message: "method not found"})
+	tc.mockClient.EXPECT().GetTransactionReceipts(gomock.Any(), gomock.Eq([]string{"success", "failed"})).Return([]*rpc.Receipt{
+		{TransactionHash: "success", Status: "0x1", GasUsed: "0x5208"},
+		{TransactionHash: "failed", Status: "0x0", GasUsed: "0x5208"},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Any()).
+		Do(func(_ string, tx *data.Transaction) {
+			assert.Equal(t, "success", tx.Hash)
+		})
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), receiptsTestBlock())
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsReceiptOfOtherBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionServiceWithConfig(ctrl, domain.TransactionServiceConfig{Receipts: true})
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true).Times(2)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(false).AnyTimes()
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Any()).Return(false).Times(2)
+	tc.mockClient.EXPECT().GetBlockReceipts(gomock.Any(), gomock.Eq("0xa")).Return([]*rpc.Receipt{
+		{TransactionHash: "success", BlockHash: "other_hash", Status: "0x1", GasUsed: "0x5208"},
+		{TransactionHash: "failed", BlockHash: "other_hash", Status: "0x0", GasUsed: "0x5208"},
+	}, nil)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), receiptsTestBlock())
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrReceiptMismatch)
+}
+
+func TestTransactionServiceRetractBlockTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().DeleteByBlockNumber(gomock.Eq(10)).Return(map[string][]data.Transaction{
+		"addr1": {{BlockNumber: 10, Hash: "hash1"}, {BlockNumber: 10, Hash: "hash2"}},
+	}, nil)
+	first := tc.mockPublisher.EXPECT().Retract(gomock.Eq("addr1"), gomock.Eq(&data.Transaction{BlockNumber: 10, Hash: "hash1"}))
+	tc.mockPublisher.EXPECT().Retract(gomock.Eq("addr1"), gomock.Eq(&data.Transaction{BlockNumber: 10, Hash: "hash2"})).After(first)
+
+	// act
+	err := tc.transactionService.RetractBlockTransactions(10)
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceRemoveByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().DeleteByAddress(gomock.Eq("0xabc"))
+
+	// act
+	err := tc.transactionService.RemoveByAddress("0xABC")
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceFetchConfirmedByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().FetchAllByAddress(gomock.Eq("addr"), gomock.Eq(8)).Return([]data.Transaction{{Hash: "hash"}}, nil)
+
+	// act
+	result, err := tc.transactionService.FetchConfirmedByAddress("addr", 10)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+}
+
+func TestTransactionServiceFetchPendingByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().
+		FindByAddress(gomock.Eq("addr"), gomock.Eq(data.TransactionFilter{FromBlock: 9, ToBlock: math.MaxInt})).
+		Return([]data.Transaction{{Hash: "hash"}})
+
+	// act
+	result := tc.transactionService.FetchPendingByAddress("addr", 10)
+
+	// assert
+	assert.Len(t, result, 1)
+}
+
+func TestTransactionServiceQueryByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	transactions := []data.Transaction{
+		{BlockNumber: 1, TransactionIndex: 0, Hash: "hash1"},
+		{BlockNumber: 1, TransactionIndex: 3, Hash: "hash2"},
+		{BlockNumber: 2, TransactionIndex: 0, Hash: "hash3"},
+	}
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().
+		FindByAddress(gomock.Eq("addr"), gomock.Eq(data.TransactionFilter{FromBlock: 1, ToBlock: 8, Limit: 3})).
+		Return(transactions)
+	tc.mockTransactionStorage.EXPECT().
+		FindByAddress(gomock.Eq("addr"), gomock.Eq(data.TransactionFilter{
+			FromBlock: 1,
+			ToBlock:   8,
+			After:     &data.TransactionPosition{BlockNumber: 1, TransactionIndex: 3},
+			Limit:     3,
+		})).
+		Return(transactions[2:])
+
+	// act
+	page, err := tc.transactionService.QueryByAddress("addr", 10, data.TransactionQuery{FromBlock: 1, Limit: 2})
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Len(t, page.Transactions, 2)
+		assert.NotEmpty(t, page.NextCursor)
+	}
+
+	// act
+	page, err = tc.transactionService.QueryByAddress("addr", 10, data.TransactionQuery{FromBlock: 1, Limit: 2, Cursor: page.NextCursor})
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Len(t, page.Transactions, 1)
+		assert.Empty(t, page.NextCursor)
+	}
+}
+
+func TestTransactionServiceQueryByAddressInvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().FindByAddress(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	_, err := tc.transactionService.QueryByAddress("addr", 10, data.TransactionQuery{Cursor: "???"})
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+func TestTransactionServiceQueryByAddressTransferCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+
+	transactions := []data.Transaction{
+		{BlockNumber: 1, Hash: "hash1"},
+		{BlockNumber: 1, Hash: "hash1", Kind: data.TransferKindERC20, EventIndex: 2},
+		{BlockNumber: 1, Hash: "hash1", Kind: data.TransferKindERC20, EventIndex: 5},
+	}
+
+	// assert
+	tc.mockTransactionStorage.EXPECT().
+		FindByAddress(gomock.Eq("addr"), gomock.Any()).
+		Return(transactions)
+	tc.mockTransactionStorage.EXPECT().
+		FindByAddress(gomock.Eq("addr"), gomock.Eq(data.TransactionFilter{
+			ToBlock: 8,
+			After:   &data.TransactionPosition{BlockNumber: 1, Kind: data.TransferKindERC20, EventIndex: 2},
+			Limit:   3,
+		})).
+		Return(transactions[2:])
+
+	// act
+	page, err := tc.transactionService.QueryByAddress("addr", 10, data.TransactionQuery{Limit: 2})
+	assert.NoError(t, err)
+	page, err = tc.transactionService.QueryByAddress("addr", 10, data.TransactionQuery{Limit: 2, Cursor: page.NextCursor})
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsForAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+	block := rpc.Block{
+		Number: "0x1",
+		Transactions: []rpc.Transaction{
+			{
+				Hash: "hash1",
+				From: "0x00000000000000000000000000000000000000a1",
+				To:   "0x00000000000000000000000000000000000000a2",
+			},
+			{
+				Hash: "hash2",
+				From: "0x00000000000000000000000000000000000000a3",
+				To:   "0x00000000000000000000000000000000000000a4",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Times(0)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("0x00000000000000000000000000000000000000a2"), gomock.Eq("hash1")).Return(false)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("0x00000000000000000000000000000000000000a2"), gomock.Any())
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactionsForAddress(context.Background(), &block, "0x00000000000000000000000000000000000000A2")
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsPublishesSaved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+	block := rpc.Block{
+		Number: "0x1",
+		Transactions: []rpc.Transaction{
+			{
+				Hash: "hash",
+				From: "addr1",
+				To:   "addr2",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Any()).Return(true).Times(2)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Any(), gomock.Eq("hash")).Return(false).Times(2)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Any()).Return(true, nil)
+	// saved meanwhile by backfill of the same block
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr2"), gomock.Any()).Return(false, nil)
+
+	tc.mockPublisher.EXPECT().Publish(gomock.Eq("addr1"), gomock.Any()).Times(1)
+	tc.mockPublisher.EXPECT().Publish(gomock.Eq("addr2"), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsSelfTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+	block := rpc.Block{
+		Number: "0x1",
+		Transactions: []rpc.Transaction{
+			{
+				Hash: "hash",
+				From: "addr1",
+				To:   "addr1",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true).Times(2)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Eq("hash")).Return(false).Times(2)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Any()).Return(true, nil).Times(1)
+	tc.mockPublisher.EXPECT().Publish(gomock.Eq("addr1"), gomock.Any()).Times(1)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceProcessBlockTransactionsSaveError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// arrange
+	tc := newUnitTransactionService(ctrl)
+	block := rpc.Block{
+		Number: "0x1",
+		Transactions: []rpc.Transaction{
+			{
+				Hash: "hash",
+				From: "addr1",
+				To:   "addr2",
+			},
+		},
+	}
+
+	// assert
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr1")).Return(true)
+	tc.mockAddressService.EXPECT().IsSubscribed(gomock.Eq("addr2")).Return(false)
+	tc.mockTransactionStorage.EXPECT().Exists(gomock.Eq("addr1"), gomock.Eq("hash")).Return(false)
+	tc.mockTransactionStorage.EXPECT().SaveForAddress(gomock.Eq("addr1"), gomock.Any()).Return(false, errors.New("disk is full"))
+	tc.mockPublisher.EXPECT().Publish(gomock.Any(), gomock.Any()).Times(0)
+
+	// act
+	err := tc.transactionService.ProcessBlockTransactions(context.Background(), &block)
+
+	// assert
+	assert.Error(t, err)
+}
@@ -3,13 +3,21 @@ package ethereum
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/rpc"
+
+	"github.com/sirupsen/logrus"
 )
 
 type (
 	AddressService interface {
-		AddUnique(address string) bool
+		AddUnique(subscription data.Subscription) (bool, error)
+		AddUniqueMany(subscriptions []data.Subscription) ([]data.Subscription, error)
+		Remove(address string) (bool, error)
+		RemoveMany(addresses []string) ([]string, error)
+		List(owner string) []data.Subscription
 	}
 
 	BlockService interface {
@@ -18,13 +26,37 @@ type (
 	}
 
 	TransactionService interface {
-		FetchAllByAddress(address string) []data.Transaction
+		FetchConfirmedByAddress(address string, head int) ([]data.Transaction, error)
+		FetchPendingByAddress(address string, head int) []data.Transaction
+		QueryByAddress(address string, head int, query data.TransactionQuery) (data.TransactionPage, error)
+		RemoveByAddress(address string) error
+	}
+
+	BackfillService interface {
+		Start(ctx context.Context, address string, from, to int) error
+		Progress(address string) (data.BackfillProgress, bool)
+		Cancel(address string)
+	}
+
+	// EventSubscription delivers events to Events channel until it ends, the channel is closed then
+	// and Err tells the reason, nil means the context was done.
+	EventSubscription interface {
+		Events() <-chan data.TransactionEvent
+		Err() error
+		// Dropped returns the number of events dropped with BackpressureDropOldest.
+		Dropped() int64
+	}
+
+	EventService interface {
+		Subscribe(ctx context.Context, options data.EventOptions) (EventSubscription, error)
 	}
 
 	Parser struct {
 		address     AddressService
 		block       BlockService
 		transaction TransactionService
+		backfill    BackfillService
+		events      EventService
 	}
 )
 
@@ -32,11 +64,15 @@ func NewParser(
 	address AddressService,
 	block BlockService,
 	transaction TransactionService,
+	backfill BackfillService,
+	events EventService,
 ) *Parser {
 	return &Parser{
 		transaction: transaction,
 		block:       block,
 		address:     address,
+		backfill:    backfill,
+		events:      events,
 	}
 }
 
@@ -49,12 +85,178 @@ func (p *Parser) GetCurrentBlock() int {
 	return value
 }
 
-func (p *Parser) Subscribe(address string) bool {
-	return p.address.AddUnique(address)
+// Subscribe starts tracking of address, it returns false when address is already tracked.
+// Address must be 0x prefixed hex, mixed-case address must have valid EIP-55 checksum.
+func (p *Parser) Subscribe(address string) (bool, error) {
+	return p.SubscribeWith(data.Subscription{Address: address})
+}
+
+// SubscribeWith starts tracking of subscription address keeping its label and owner,
+// creation block is set to the current block.
+func (p *Parser) SubscribeWith(subscription data.Subscription) (bool, error) {
+	subscription.CreatedAtBlock = p.GetCurrentBlock()
+
+	added, err := p.address.AddUnique(subscription)
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	return added, nil
+}
+
+// SubscribeMany starts tracking of many addresses at once, e.g. when watch list is imported.
+// Nothing is subscribed when any address is invalid, it returns the number of newly tracked addresses.
+func (p *Parser) SubscribeMany(subscriptions []data.Subscription) (int, error) {
+	current := p.GetCurrentBlock()
+
+	batch := make([]data.Subscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		subscription.CreatedAtBlock = current
+		batch[i] = subscription
+	}
+
+	added, err := p.address.AddUniqueMany(batch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	return len(added), nil
+}
+
+// SubscribeFrom starts tracking of subscription address and scans its history from fromBlock
+// up to the current block in background, new blocks are processed as usual meanwhile.
+// History is scanned even when address is already tracked, saved transactions are not duplicated.
+// Backfill stops when ctx is done, its state is reported by BackfillProgress.
+func (p *Parser) SubscribeFrom(ctx context.Context, subscription data.Subscription, fromBlock int) (bool, error) {
+	subscription.CreatedAtBlock = p.GetCurrentBlock()
+
+	added, err := p.address.AddUnique(subscription)
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	if fromBlock > subscription.CreatedAtBlock {
+		return added, nil
+	}
+
+	// block at subscription was processed before address was added, so it is scanned too
+	err = p.backfill.Start(ctx, subscription.Address, fromBlock, subscription.CreatedAtBlock)
+	if err != nil {
+		return added, fmt.Errorf("failed to start backfill: %w", err)
+	}
+
+	return added, nil
+}
+
+// BackfillProgress returns state of the last history scan of address, ok is false when none was started.
+func (p *Parser) BackfillProgress(address string) (data.BackfillProgress, bool) {
+	return p.backfill.Progress(address)
 }
 
+// Unsubscribe stops tracking of address and removes its transactions, it returns false
+// when address is not tracked.
+func (p *Parser) Unsubscribe(address string) (bool, error) {
+	removed, err := p.address.Remove(address)
+	if err != nil {
+		return false, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	if removed {
+		p.backfill.Cancel(address)
+		if err := p.transaction.RemoveByAddress(address); err != nil {
+			return true, fmt.Errorf("failed to remove transactions: %w", err)
+		}
+	}
+
+	return removed, nil
+}
+
+// UnsubscribeMany stops tracking of many addresses at once and removes their transactions.
+// Nothing is unsubscribed when any address is invalid, it returns the number of addresses which were tracked.
+func (p *Parser) UnsubscribeMany(addresses []string) (int, error) {
+	removed, err := p.address.RemoveMany(addresses)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	for _, address := range removed {
+		p.backfill.Cancel(address)
+		if err := p.transaction.RemoveByAddress(address); err != nil {
+			return len(removed), fmt.Errorf("failed to remove transactions: %w", err)
+		}
+	}
+
+	return len(removed), nil
+}
+
+// ListSubscriptions returns subscriptions of owner in the order their addresses were subscribed,
+// empty owner means every subscription.
+func (p *Parser) ListSubscriptions(owner string) []data.Subscription {
+	return p.address.List(owner)
+}
+
+// GetTransactions returns once confirmed transactions of address.
+// It is the drain-style call kept for compatibility, history stays available via QueryTransactions.
+// Transactions which could not be marked as delivered are not returned, they are returned by the next call.
 func (p *Parser) GetTransactions(address string) []data.Transaction {
-	return p.transaction.FetchAllByAddress(address)
+	transactions, err := p.transaction.FetchConfirmedByAddress(address, p.GetCurrentBlock())
+	if err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"address": address,
+			}).
+			WithError(err).
+			Error("failed to get transactions")
+
+		return nil
+	}
+
+	return transactions
+}
+
+// GetPendingTransactions returns transactions of address which are not confirmed yet.
+func (p *Parser) GetPendingTransactions(address string) []data.Transaction {
+	return p.transaction.FetchPendingByAddress(address, p.GetCurrentBlock())
+}
+
+// QueryTransactions returns a page of confirmed transactions of address.
+// Unlike GetTransactions it does not change delivery state, so every consumer
+// can page through the whole history with its own cursor.
+func (p *Parser) QueryTransactions(address string, query data.TransactionQuery) (data.TransactionPage, error) {
+	page, err := p.transaction.QueryByAddress(address, p.GetCurrentBlock(), query)
+	if err != nil {
+		return data.TransactionPage{}, fmt.Errorf("failed to query transactions: %w", err)
+	}
+
+	return page, nil
+}
+
+// Events pushes every transaction of subscribed addresses as soon as it is saved, including
+// not confirmed ones and ones found by backfill, and retracted event of every saved one whose block
+// was reorganized out. Channel of subscription is closed when ctx is done
+// or, with BackpressureError, when consumer falls behind by more than the buffer.
+func (p *Parser) Events(ctx context.Context, options data.EventOptions) (EventSubscription, error) {
+	subscription, err := p.events.Subscribe(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// OnTransaction calls handler for every event until ctx is done, handler runs in the calling goroutine.
+// It returns nil on cancellation and domain.ErrEventOverflow when events were lost with BackpressureError.
+func (p *Parser) OnTransaction(ctx context.Context, options data.EventOptions, handler func(event data.TransactionEvent)) error {
+	subscription, err := p.Events(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	for event := range subscription.Events() {
+		handler(event)
+	}
+
+	return subscription.Err()
 }
 
 func (p *Parser) MonitorTransactions(ctx context.Context) error {
@@ -65,3 +267,36 @@ func (p *Parser) MonitorTransactions(ctx context.Context) error {
 
 	return nil
 }
+
+// Run processes new blocks on every head received from heads until ctx is done.
+// When no head arrives during pollInterval blocks are polled, so nil heads means polling only.
+// Failed processing is logged and repeated on the next head or poll, processed blocks are kept.
+func (p *Parser) Run(ctx context.Context, heads <-chan rpc.Header, pollInterval time.Duration) {
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+
+	for {
+		if err := p.MonitorTransactions(ctx); err != nil && ctx.Err() == nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"poll_interval": pollInterval,
+				}).
+				WithError(err).
+				Error("failed to monitor transactions, will retry")
+		}
+
+		timer.Reset(pollInterval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case head := <-heads:
+			logrus.
+				WithFields(logrus.Fields{
+					"block_number": head.Number,
+				}).
+				Debug("New head was received")
+		case <-timer.C:
+		}
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"trust_walet/internal/ethereum"
+	"trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/domain"
 	"trust_walet/internal/ethereum/rpc"
 	"trust_walet/internal/ethereum/storage"
@@ -21,8 +24,8 @@ func TestParserMonitorTransactions(t *testing.T) {
 		response := createGetBlockResponse(
 			createBlockResponse(1,
 				[]map[string]interface{}{
-					createTransactionResponse("0x1", "addr1", "addr2"),
-					createTransactionResponse("0x2", "addr2", "addr3"),
+					createTransactionResponse("0x1", "0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000a2"),
+					createTransactionResponse("0x2", "0x00000000000000000000000000000000000000a2", "0x00000000000000000000000000000000000000a3"),
 				},
 			),
 		)
@@ -37,37 +40,408 @@ func TestParserMonitorTransactions(t *testing.T) {
 	// arrange
 	ctx := context.Background()
 
-	client := rpc.NewHttp(&http.Client{}, server.URL)
+	parser := createParser(server.URL, 0)
+
+	// act
+	added, err := parser.Subscribe("0x00000000000000000000000000000000000000A2")
+	parser.MonitorTransactions(ctx)
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.Equal(t, parser.GetCurrentBlock(), 1)
+
+	tx := parser.GetTransactions("0x00000000000000000000000000000000000000a2")
+	if assert.Len(t, tx, 2) {
+		assert.Equal(t, data.Transaction{
+			BlockNumber:      1,
+			BlockHash:        "0xhash1",
+			Timestamp:        0x66e88f3b,
+			TransactionIndex: 1,
+			Hash:             "0x2",
+			From:             "0x00000000000000000000000000000000000000a2",
+			To:               "0x00000000000000000000000000000000000000a3",
+			Value:            "0xf3",
+			Nonce:            5,
+			Gas:              21000,
+			GasPrice:         "0x3b9aca00",
+			Input:            "0x",
+		}, tx[1])
+	}
+	assert.Empty(t, parser.GetTransactions("0x00000000000000000000000000000000000000a2"))
+
+	page, err := parser.QueryTransactions("0x00000000000000000000000000000000000000a2", data.TransactionQuery{})
+	if assert.NoError(t, err) {
+		assert.Len(t, page.Transactions, 2)
+	}
+}
+
+func TestParserMonitorTransactionsPending(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := createGetBlockResponse(
+			createBlockResponse(1,
+				[]map[string]interface{}{
+					createTransactionResponse("0x1", "0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000a2"),
+				},
+			),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Error encoding JSON", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	// arrange
+	ctx := context.Background()
+	parser := createParser(server.URL, 1)
+
+	// act
+	parser.Subscribe("0x00000000000000000000000000000000000000a2")
+	parser.MonitorTransactions(ctx)
+
+	// assert
+	assert.Empty(t, parser.GetTransactions("0x00000000000000000000000000000000000000a2"))
+	assert.Len(t, parser.GetPendingTransactions("0x00000000000000000000000000000000000000a2"), 1)
+}
+
+func TestParserRunHeads(t *testing.T) {
+	var latestRequests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request["params"].([]interface{})[0] == "latest" {
+			atomic.AddInt32(&latestRequests, 1)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(createGetBlockResponse(createBlockResponse(1, nil)))
+	}))
+	defer server.Close()
+
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parser := createParser(server.URL, 0)
+	heads := make(chan rpc.Header)
+	done := make(chan struct{})
+
+	// act
+	go func() {
+		parser.Run(ctx, heads, time.Hour)
+		close(done)
+	}()
+	heads <- rpc.Header{Number: "0x1"}
+
+	// assert
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&latestRequests) >= 2
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Equal(t, 1, parser.GetCurrentBlock())
+}
+
+func TestParserRunRecoversFromError(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(createGetBlockResponse(createBlockResponse(1, nil)))
+	}))
+	defer server.Close()
+
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parser := createParser(server.URL, 0)
+	done := make(chan struct{})
+
+	// act
+	go func() {
+		parser.Run(ctx, nil, 10*time.Millisecond)
+		close(done)
+	}()
+
+	// assert
+	assert.Eventually(t, func() bool {
+		return parser.GetCurrentBlock() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestParserSubscribeInvalidAddress(t *testing.T) {
+	// arrange
+	parser := createParser("", 0)
+
+	// act
+	added, err := parser.Subscribe("addr")
+
+	// assert
+	assert.ErrorIs(t, err, data.ErrInvalidAddress)
+	assert.False(t, added)
+}
+
+func TestParserSubscriptionLifecycle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := createGetBlockResponse(
+			createBlockResponse(1,
+				[]map[string]interface{}{
+					createTransactionResponse("0x1", "0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000a2"),
+				},
+			),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	// arrange
+	ctx := context.Background()
+	parser := createParser(server.URL, 0)
+
+	// act
+	parser.SubscribeWith(data.Subscription{Address: "0x00000000000000000000000000000000000000a1", Label: "hot", Owner: "tenant"})
+	parser.MonitorTransactions(ctx)
+	parser.Subscribe("0x00000000000000000000000000000000000000a2")
+
+	// assert
+	assert.Equal(t, []data.Subscription{
+		{Address: "0x00000000000000000000000000000000000000a1", Label: "hot", Owner: "tenant"},
+		{Address: "0x00000000000000000000000000000000000000a2", CreatedAtBlock: 1},
+	}, parser.ListSubscriptions(""))
+	assert.Len(t, parser.ListSubscriptions("tenant"), 1)
+
+	// act
+	removed, err := parser.Unsubscribe("0x00000000000000000000000000000000000000a1")
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Empty(t, parser.GetTransactions("0x00000000000000000000000000000000000000a1"))
+	assert.Len(t, parser.ListSubscriptions(""), 1)
+
+	// act
+	removed, err = parser.Unsubscribe("0x00000000000000000000000000000000000000a1")
+
+	// assert
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestParserSubscribeMany(t *testing.T) {
+	// arrange
+	parser := createParser("", 0)
+	parser.Subscribe("0x00000000000000000000000000000000000000a1")
+
+	// act
+	added, err := parser.SubscribeMany([]data.Subscription{
+		{Address: "0x00000000000000000000000000000000000000a1"},
+		{Address: "0x00000000000000000000000000000000000000A2", Owner: "tenant"},
+		{Address: "0x00000000000000000000000000000000000000a3", Owner: "tenant"},
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Len(t, parser.ListSubscriptions("tenant"), 2)
+
+	// act
+	removed, err := parser.UnsubscribeMany([]string{
+		"0x00000000000000000000000000000000000000a1",
+		"0x00000000000000000000000000000000000000a2",
+		"0x00000000000000000000000000000000000000a4",
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, []data.Subscription{
+		{Address: "0x00000000000000000000000000000000000000a3", Owner: "tenant"},
+	}, parser.ListSubscriptions(""))
+
+	// act
+	_, err = parser.SubscribeMany([]data.Subscription{{Address: "addr"}})
+
+	// assert
+	assert.ErrorIs(t, err, data.ErrInvalidAddress)
+}
+
+func TestParserSubscribeFrom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		number := 3
+		if param := request["params"].([]interface{})[0]; param != "latest" {
+			fmt.Sscanf(param.(string), "0x%x", &number)
+		}
+
+		response := createGetBlockResponse(
+			createBlockResponse(number,
+				[]map[string]interface{}{
+					createTransactionResponse(fmt.Sprintf("0x%x", number), "0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000a2"),
+				},
+			),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	// arrange
+	ctx := context.Background()
+	parser := createParser(server.URL, 0)
+	parser.MonitorTransactions(ctx)
+
+	// act
+	added, err := parser.SubscribeFrom(ctx, data.Subscription{Address: "0x00000000000000000000000000000000000000a2"}, 1)
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.Eventually(t, func() bool {
+		progress, ok := parser.BackfillProgress("0x00000000000000000000000000000000000000a2")
+
+		return ok && progress.State == data.BackfillStateDone
+	}, time.Second, 10*time.Millisecond)
+
+	// act
+	parser.MonitorTransactions(ctx)
+
+	// assert
+	page, err := parser.QueryTransactions("0x00000000000000000000000000000000000000a2", data.TransactionQuery{})
+	if assert.NoError(t, err) && assert.Len(t, page.Transactions, 3) {
+		assert.Equal(t, "0x1", page.Transactions[0].Hash)
+		assert.Equal(t, "0x3", page.Transactions[2].Hash)
+	}
+}
+
+func TestParserEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := createGetBlockResponse(
+			createBlockResponse(1,
+				[]map[string]interface{}{
+					createTransactionResponse("0x1", "0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000a2"),
+				},
+			),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	parser := createParser(server.URL, 12)
+	parser.Subscribe("0x00000000000000000000000000000000000000a2")
+
+	subscription, err := parser.Events(ctx, data.EventOptions{})
+	assert.NoError(t, err)
+
+	// act
+	parser.MonitorTransactions(ctx)
+	cancel()
+
+	// assert
+	var events []data.TransactionEvent
+	for event := range subscription.Events() {
+		events = append(events, event)
+	}
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "0x00000000000000000000000000000000000000a2", events[0].Address)
+		assert.Equal(t, "0x1", events[0].Transaction.Hash)
+	}
+	assert.NoError(t, subscription.Err())
+	assert.Empty(t, parser.GetTransactions("0x00000000000000000000000000000000000000a2"))
+}
+
+func TestParserOnTransaction(t *testing.T) {
+	// arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	parser := createParser("", 0)
+
+	// act
+	err := parser.OnTransaction(ctx, data.EventOptions{Backpressure: "wait"}, func(data.TransactionEvent) {})
+
+	// assert
+	assert.ErrorIs(t, err, domain.ErrInvalidBackpressure)
+
+	// act
+	cancel()
+	err = parser.OnTransaction(ctx, data.EventOptions{}, func(data.TransactionEvent) {})
+
+	// assert
+	assert.NoError(t, err)
+}
+
+func createParser(url string, confirmations int) *ethereum.Parser {
+	client := rpc.NewHttp(&http.Client{}, url)
 
 	addressService := domain.NewAddressService(
 		storage.NewAddressInMemory(),
 	)
+	eventService := domain.NewEventService()
 	transactionService := domain.NewTransactionService(
 		client,
 		addressService,
 		storage.NewTransactionInMemory(),
+		eventService,
+		domain.TransactionServiceConfig{
+			Confirmations: confirmations,
+		},
 	)
 	blockService := domain.NewBlockService(
 		client,
 		storage.NewBlockInMemory(),
 		transactionService,
+		domain.BlockServiceConfig{
+			ReorgWindow: domain.DefaultReorgWindow,
+		},
+	)
+
+	backfillService := domain.NewBackfillService(
+		blockService,
+		transactionService,
 	)
 
-	parser := ethereum.NewParser(
+	return ethereum.NewParser(
 		addressService,
 		blockService,
 		transactionService,
+		backfillService,
+		eventServiceAdapter{eventService},
 	)
+}
 
-	// act
-	parser.Subscribe("addr2")
-	parser.MonitorTransactions(ctx)
+// eventServiceAdapter adapts domain.EventService to ethereum.EventService, which does not depend on domain types.
+type eventServiceAdapter struct {
+	*domain.EventService
+}
 
-	// assert
-	assert.Equal(t, parser.GetCurrentBlock(), 1)
+func (e eventServiceAdapter) Subscribe(ctx context.Context, options data.EventOptions) (ethereum.EventSubscription, error) {
+	subscription, err := e.EventService.Subscribe(ctx, options)
+	if err != nil {
+		// nil subscription must not become non-nil interface
+		return nil, err
+	}
 
-	tx := parser.GetTransactions("addr2")
-	assert.Len(t, tx, 2)
+	return subscription, nil
 }
 
 func createTransactionResponse(hash, from, to string) map[string]interface{} {
@@ -76,6 +450,11 @@ func createTransactionResponse(hash, from, to string) map[string]interface{} {
 	transaction["from"] = from
 	transaction["to"] = to
 	transaction["value"] = "0xf3"
+	transaction["nonce"] = "0x5"
+	transaction["gas"] = "0x5208"
+	transaction["gasPrice"] = "0x3b9aca00"
+	transaction["type"] = "0x0"
+	transaction["input"] = "0x"
 
 	return transaction
 }
@@ -83,6 +462,8 @@ func createTransactionResponse(hash, from, to string) map[string]interface{} {
 func createBlockResponse(number int, transactions []map[string]interface{}) map[string]interface{} {
 	block := make(map[string]interface{})
 	block["number"] = fmt.Sprintf("0x%x", number)
+	block["hash"] = fmt.Sprintf("0xhash%d", number)
+	block["timestamp"] = "0x66e88f3b"
 	block["transactions"] = transactions
 
 	return block
@@ -0,0 +1,293 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	EthereumUrl = "https://ethereum-rpc.publicnode.com"
+
+	NumberLatest = "latest"
+
+	methodGetBlockByNumber      = "eth_getBlockByNumber"
+	methodGetTransactionReceipt = "eth_getTransactionReceipt"
+	methodGetBlockReceipts      = "eth_getBlockReceipts"
+	methodGetLogs               = "eth_getLogs"
+	methodTraceBlockByNumber    = "debug_traceBlockByNumber"
+	methodTraceBlock            = "trace_block"
+
+	tracerCall = "callTracer"
+
+	ReceiptStatusSuccess = "0x1"
+	ReceiptStatusFailed  = "0x0"
+)
+
+type (
+	Block struct {
+		Number       string        `json:"number"`
+		Hash         string        `json:"hash"`
+		ParentHash   string        `json:"parentHash"`
+		Timestamp    string        `json:"timestamp"`
+		Transactions []Transaction `json:"transactions"`
+	}
+
+	// Transaction keeps quantities hex encoded as node returns them.
+	Transaction struct {
+		BlockHash            string `json:"blockHash"`
+		BlockNumber          string `json:"blockNumber"`
+		TransactionIndex     string `json:"transactionIndex"`
+		Hash                 string `json:"hash"`
+		From                 string `json:"from"`
+		To                   string `json:"to,omitempty"`
+		Value                string `json:"value"`
+		Nonce                string `json:"nonce"`
+		Gas                  string `json:"gas"`
+		GasPrice             string `json:"gasPrice"`
+		MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+		MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+		Type                 string `json:"type"`
+		ChainID              string `json:"chainId,omitempty"`
+		Input                string `json:"input"`
+	}
+
+	// Receipt is the result of transaction execution, Status is empty for blocks before Byzantium fork.
+	Receipt struct {
+		TransactionHash   string `json:"transactionHash"`
+		TransactionIndex  string `json:"transactionIndex"`
+		BlockHash         string `json:"blockHash"`
+		BlockNumber       string `json:"blockNumber"`
+		From              string `json:"from"`
+		To                string `json:"to,omitempty"`
+		Status            string `json:"status,omitempty"`
+		GasUsed           string `json:"gasUsed"`
+		EffectiveGasPrice string `json:"effectiveGasPrice"`
+		ContractAddress   string `json:"contractAddress,omitempty"`
+	}
+
+	// Log is an event emitted by contract, Removed is set when the log was dropped by reorganization.
+	Log struct {
+		Address          string   `json:"address"`
+		Topics           []string `json:"topics"`
+		Data             string   `json:"data"`
+		BlockNumber      string   `json:"blockNumber"`
+		BlockHash        string   `json:"blockHash"`
+		TransactionHash  string   `json:"transactionHash"`
+		TransactionIndex string   `json:"transactionIndex"`
+		LogIndex         string   `json:"logIndex"`
+		Removed          bool     `json:"removed"`
+	}
+
+	// LogFilter selects logs either of a single block by BlockHash or of a block range.
+	// Topics are matched by position, every position lists alternatives and empty position matches any topic.
+	LogFilter struct {
+		BlockHash string     `json:"blockHash,omitempty"`
+		FromBlock string     `json:"fromBlock,omitempty"`
+		ToBlock   string     `json:"toBlock,omitempty"`
+		Address   []string   `json:"address,omitempty"`
+		Topics    [][]string `json:"topics,omitempty"`
+	}
+
+	// CallFrame is a call traced by callTracer, Calls are calls made by it in execution order.
+	// Error is set when the call reverted, calls made by it are reverted too.
+	CallFrame struct {
+		Type    string      `json:"type"`
+		From    string      `json:"from"`
+		To      string      `json:"to,omitempty"`
+		Value   string      `json:"value,omitempty"`
+		Gas     string      `json:"gas,omitempty"`
+		GasUsed string      `json:"gasUsed,omitempty"`
+		Input   string      `json:"input,omitempty"`
+		Output  string      `json:"output,omitempty"`
+		Error   string      `json:"error,omitempty"`
+		Calls   []CallFrame `json:"calls,omitempty"`
+	}
+
+	// TransactionTrace is callTracer result for transaction of block, TxHash is missing on older nodes,
+	// then traces are in the order of block transactions.
+	TransactionTrace struct {
+		TxHash string     `json:"txHash,omitempty"`
+		Result *CallFrame `json:"result"`
+		Error  string     `json:"error,omitempty"`
+	}
+
+	// Trace is a call of trace_block flat list, TraceAddress is the path of the call in call tree,
+	// so the top level call has empty TraceAddress.
+	Trace struct {
+		Type                string       `json:"type"`
+		Action              TraceAction  `json:"action"`
+		Result              *TraceResult `json:"result,omitempty"`
+		Error               string       `json:"error,omitempty"`
+		TraceAddress        []int        `json:"traceAddress"`
+		BlockHash           string       `json:"blockHash"`
+		TransactionHash     string       `json:"transactionHash"`
+		TransactionPosition int          `json:"transactionPosition"`
+	}
+
+	// TraceAction keeps From, To and Value of call and create, Address, RefundAddress and Balance of suicide.
+	TraceAction struct {
+		CallType      string `json:"callType,omitempty"`
+		From          string `json:"from,omitempty"`
+		To            string `json:"to,omitempty"`
+		Value         string `json:"value,omitempty"`
+		Address       string `json:"address,omitempty"`
+		RefundAddress string `json:"refundAddress,omitempty"`
+		Balance       string `json:"balance,omitempty"`
+	}
+
+	// TraceResult keeps Address of created contract.
+	TraceResult struct {
+		Address string `json:"address,omitempty"`
+	}
+
+	// Caller sends JSON-RPC calls over some transport.
+	Caller interface {
+		Call(ctx context.Context, result interface{}, method string, params ...interface{}) error
+		BatchCall(ctx context.Context, elems []BatchElem) error
+	}
+
+	// Client provides typed Ethereum methods on top of any Caller.
+	Client struct {
+		caller Caller
+	}
+)
+
+func NewClient(caller Caller) *Client {
+	return &Client{
+		caller: caller,
+	}
+}
+
+// GetBlockByNumber returns ErrBlockNotFound when node answers with null, e.g. the block is not produced yet.
+func (c *Client) GetBlockByNumber(ctx context.Context, number string) (*Block, error) {
+	var block *Block
+	if err := c.caller.Call(ctx, &block, methodGetBlockByNumber, number, true); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("error during %s request for block %s: %w", methodGetBlockByNumber, number, ErrBlockNotFound)
+	}
+
+	return block, nil
+}
+
+// GetBlocksByNumber fetches blocks with transactions in one batch request, blocks are in the order of numbers.
+func (c *Client) GetBlocksByNumber(ctx context.Context, numbers []string) ([]*Block, error) {
+	blocks := make([]*Block, len(numbers))
+	elems := make([]BatchElem, len(numbers))
+	for i, number := range numbers {
+		elems[i] = BatchElem{
+			Method: methodGetBlockByNumber,
+			Params: []interface{}{number, true},
+			Result: &blocks[i],
+		}
+	}
+
+	if err := c.caller.BatchCall(ctx, elems); err != nil {
+		return nil, err
+	}
+
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("error during %s request for block %s: %w", methodGetBlockByNumber, numbers[i], elem.Error)
+		}
+		if blocks[i] == nil {
+			return nil, fmt.Errorf("error during %s request for block %s: %w", methodGetBlockByNumber, numbers[i], ErrBlockNotFound)
+		}
+	}
+
+	return blocks, nil
+}
+
+// GetTransactionReceipt returns ErrReceiptNotFound when transaction is not mined yet.
+func (c *Client) GetTransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	var receipt *Receipt
+	if err := c.caller.Call(ctx, &receipt, methodGetTransactionReceipt, hash); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("error during %s request for transaction %s: %w", methodGetTransactionReceipt, hash, ErrReceiptNotFound)
+	}
+
+	return receipt, nil
+}
+
+// GetTransactionReceipts fetches receipts in one batch request, receipts are in the order of hashes.
+func (c *Client) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*Receipt, error) {
+	receipts := make([]*Receipt, len(hashes))
+	elems := make([]BatchElem, len(hashes))
+	for i, hash := range hashes {
+		elems[i] = BatchElem{
+			Method: methodGetTransactionReceipt,
+			Params: []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+
+	if err := c.caller.BatchCall(ctx, elems); err != nil {
+		return nil, err
+	}
+
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("error during %s request for transaction %s: %w", methodGetTransactionReceipt, hashes[i], elem.Error)
+		}
+		if receipts[i] == nil {
+			return nil, fmt.Errorf("error during %s request for transaction %s: %w", methodGetTransactionReceipt, hashes[i], ErrReceiptNotFound)
+		}
+	}
+
+	return receipts, nil
+}
+
+// GetBlockReceipts returns receipts of all block transactions, not every node supports it, see IsMethodNotFound.
+func (c *Client) GetBlockReceipts(ctx context.Context, number string) ([]*Receipt, error) {
+	var receipts []*Receipt
+	if err := c.caller.Call(ctx, &receipts, methodGetBlockReceipts, number); err != nil {
+		return nil, err
+	}
+	if receipts == nil {
+		return nil, fmt.Errorf("error during %s request for block %s: %w", methodGetBlockReceipts, number, ErrBlockNotFound)
+	}
+
+	return receipts, nil
+}
+
+// GetLogs returns logs matching the filter, filter by BlockHash fails when node does not know the block.
+func (c *Client) GetLogs(ctx context.Context, filter LogFilter) ([]*Log, error) {
+	var logs []*Log
+	if err := c.caller.Call(ctx, &logs, methodGetLogs, filter); err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []*Log{}
+	}
+
+	return logs, nil
+}
+
+// TraceBlockByNumber traces calls of every block transaction with callTracer, it requires debug API.
+func (c *Client) TraceBlockByNumber(ctx context.Context, number string) ([]*TransactionTrace, error) {
+	var traces []*TransactionTrace
+	if err := c.caller.Call(ctx, &traces, methodTraceBlockByNumber, number, map[string]string{"tracer": tracerCall}); err != nil {
+		return nil, err
+	}
+	if traces == nil {
+		return nil, fmt.Errorf("error during %s request for block %s: %w", methodTraceBlockByNumber, number, ErrBlockNotFound)
+	}
+
+	return traces, nil
+}
+
+// TraceBlock returns flat call traces of block, it requires trace API.
+func (c *Client) TraceBlock(ctx context.Context, number string) ([]*Trace, error) {
+	var traces []*Trace
+	if err := c.caller.Call(ctx, &traces, methodTraceBlock, number); err != nil {
+		return nil, err
+	}
+	if traces == nil {
+		return nil, fmt.Errorf("error during %s request for block %s: %w", methodTraceBlock, number, ErrBlockNotFound)
+	}
+
+	return traces, nil
+}
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSON-RPC error codes, see EIP-1474.
+const (
+	CodeInvalidRequest   = -32600
+	CodeMethodNotFound   = -32601
+	CodeInvalidParams    = -32602
+	CodeInternalError    = -32603
+	CodeServerError      = -32000
+	CodeResourceNotFound = -32001
+	CodeLimitExceeded    = -32005
+)
+
+// Error is a failed JSON-RPC call. Code is set when node answered with JSON-RPC error,
+// HTTPStatus is set when HTTP request itself was rejected.
+type Error struct {
+	Method     string
+	Code       int
+	Message    string
+	Data       json.RawMessage
+	HTTPStatus int
+	// RetryAfter is the delay requested by node in Retry-After header.
+	RetryAfter time.Duration
+}
+
+var (
+	ErrBlockNotFound   = errors.New("block is not found")
+	ErrReceiptNotFound = errors.New("transaction receipt is not found")
+)
+
+func newResponseError(method string, resp *rpcError) *Error {
+	return &Error{
+		Method:  method,
+		Code:    resp.Code,
+		Message: resp.Message,
+		Data:    resp.Data,
+	}
+}
+
+func newStatusError(method string, resp *http.Response) *Error {
+	return &Error{
+		Method:     method,
+		Message:    http.StatusText(resp.StatusCode),
+		HTTPStatus: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter accepts both delay in seconds and HTTP date, invalid value means no delay.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
+
+func (e *Error) Error() string {
+	if e.HTTPStatus != 0 && e.HTTPStatus != http.StatusOK {
+		return fmt.Sprintf("%s request failed with HTTP status %d: %s", e.Method, e.HTTPStatus, e.Message)
+	}
+
+	return fmt.Sprintf("%s request failed with rpc error %d: %s", e.Method, e.Code, e.Message)
+}
+
+// Unwrap keeps the error comparable with ErrEthereumServerUnavailable and ErrRPCResponseError.
+func (e *Error) Unwrap() error {
+	if e.HTTPStatus != 0 && e.HTTPStatus != http.StatusOK {
+		return ErrEthereumServerUnavailable
+	}
+
+	return ErrRPCResponseError
+}
+
+// IsRateLimited reports whether node rejected the call because of request limits.
+func IsRateLimited(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	if rpcErr.HTTPStatus == http.StatusTooManyRequests || rpcErr.Code == CodeLimitExceeded {
+		return true
+	}
+
+	message := strings.ToLower(rpcErr.Message)
+
+	return strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests")
+}
+
+// IsBlockNotFound reports whether requested block is unknown to node, e.g. it is not synced yet.
+func IsBlockNotFound(err error) bool {
+	if errors.Is(err, ErrBlockNotFound) {
+		return true
+	}
+
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	return rpcErr.Code == CodeResourceNotFound ||
+		strings.Contains(strings.ToLower(rpcErr.Message), "block not found")
+}
+
+// IsInvalidParams reports whether the call was rejected because of its parameters,
+// so repeating it makes no sense.
+func IsInvalidParams(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	return rpcErr.Code == CodeInvalidParams
+}
+
+// IsMethodNotFound reports whether node does not support the method.
+func IsMethodNotFound(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	message := strings.ToLower(rpcErr.Message)
+
+	return rpcErr.Code == CodeMethodNotFound ||
+		strings.Contains(message, "method not found") ||
+		strings.Contains(message, "does not exist")
+}
+
+// transientMessages are parts of CodeServerError messages of failures which pass by themselves,
+// the code is used by nodes for permanent ones as well, e.g. reverted execution or invalid nonce.
+var transientMessages = []string{
+	"header not found",
+	"timeout",
+	"timed out",
+	"try again",
+	"temporarily unavailable",
+	"busy",
+}
+
+// IsServerError reports whether node failed to handle a valid call, such calls may succeed later.
+func IsServerError(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	if rpcErr.HTTPStatus >= http.StatusInternalServerError || rpcErr.Code == CodeInternalError {
+		return true
+	}
+	if rpcErr.Code != CodeServerError {
+		return false
+	}
+
+	message := strings.ToLower(rpcErr.Message)
+	for _, transient := range transientMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+
+	return false
+}
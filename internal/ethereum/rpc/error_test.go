@@ -0,0 +1,99 @@
+package rpc_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/rpc"
+)
+
+func TestErrorUnwrap(t *testing.T) {
+	// arrange
+	responseErr := &rpc.Error{Method: "eth_call", Code: rpc.CodeInvalidParams, Message: "invalid argument"}
+	statusErr := &rpc.Error{Method: "eth_call", HTTPStatus: http.StatusBadGateway}
+
+	// assert
+	assert.ErrorIs(t, responseErr, rpc.ErrRPCResponseError)
+	assert.NotErrorIs(t, responseErr, rpc.ErrEthereumServerUnavailable)
+	assert.ErrorIs(t, statusErr, rpc.ErrEthereumServerUnavailable)
+	assert.NotErrorIs(t, statusErr, rpc.ErrRPCResponseError)
+}
+
+func TestErrorClassification(t *testing.T) {
+	cases := []struct {
+		name          string
+		err           error
+		rateLimited   bool
+		blockNotFound bool
+		invalidParams bool
+		serverError   bool
+	}{
+		{
+			name:        "http too many requests",
+			err:         &rpc.Error{HTTPStatus: http.StatusTooManyRequests},
+			rateLimited: true,
+		},
+		{
+			name:        "limit exceeded code",
+			err:         &rpc.Error{Code: rpc.CodeLimitExceeded, Message: "limit exceeded"},
+			rateLimited: true,
+		},
+		{
+			name:        "rate limit message",
+			err:         &rpc.Error{Code: 429, Message: "Rate limit reached"},
+			rateLimited: true,
+		},
+		{
+			name:          "resource not found code",
+			err:           &rpc.Error{Code: rpc.CodeResourceNotFound, Message: "resource not found"},
+			blockNotFound: true,
+		},
+		{
+			name:          "null block result",
+			err:           fmt.Errorf("wrapped: %w", rpc.ErrBlockNotFound),
+			blockNotFound: true,
+		},
+		{
+			name:          "invalid params",
+			err:           fmt.Errorf("wrapped: %w", &rpc.Error{Code: rpc.CodeInvalidParams}),
+			invalidParams: true,
+		},
+		{
+			name:        "internal error",
+			err:         &rpc.Error{Code: rpc.CodeInternalError},
+			serverError: true,
+		},
+		{
+			name:        "http bad gateway",
+			err:         &rpc.Error{HTTPStatus: http.StatusBadGateway},
+			serverError: true,
+		},
+		{
+			name:        "transient server error",
+			err:         &rpc.Error{Code: rpc.CodeServerError, Message: "header not found"},
+			serverError: true,
+		},
+		{
+			name: "execution reverted",
+			err:  &rpc.Error{Code: rpc.CodeServerError, Message: "execution reverted"},
+		},
+		{
+			name: "not rpc error",
+			err:  errors.New("any error"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// assert
+			assert.Equal(t, c.rateLimited, rpc.IsRateLimited(c.err))
+			assert.Equal(t, c.blockNotFound, rpc.IsBlockNotFound(c.err))
+			assert.Equal(t, c.invalidParams, rpc.IsInvalidParams(c.err))
+			assert.Equal(t, c.serverError, rpc.IsServerError(c.err))
+		})
+	}
+}
@@ -4,102 +4,106 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"sync/atomic"
 )
 
-const (
-	EthereumUrl = "https://ethereum-rpc.publicnode.com"
+// Http is JSON-RPC client over HTTP, typed methods come from embedded Client.
+type Http struct {
+	*Client
 
-	NumberLatest = "latest"
+	client *http.Client
 
-	rpcVersion = "2.0"
-
-	methodGetBlockByNumber = "eth_getBlockByNumber"
-)
+	idCounter uint64
+	url       string
+}
 
-type (
-	Block struct {
-		Number       string        `json:"number"`
-		Transactions []Transaction `json:"transactions"`
+func NewHttp(
+	client *http.Client,
+	url string,
+) *Http {
+	h := &Http{
+		client:    client,
+		idCounter: 0,
+		url:       url,
 	}
+	h.Client = NewClient(h)
+
+	return h
+}
+
+func (r *Http) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	reqBody := newRPCRequest(atomic.AddUint64(&r.idCounter, 1), method, params)
 
-	Transaction struct {
-		Hash  string `json:"hash"`
-		From  string `json:"from"`
-		To    string `json:"to,omitempty"`
-		Value string `json:"value"`
+	resp, err := r.sendRequest(ctx, &reqBody)
+	if err != nil {
+		return fmt.Errorf("error during %s request: %w", method, err)
 	}
 
-	rpcRequest struct {
-		JSONRPC string        `json:"jsonrpc"`
-		Method  string        `json:"method"`
-		Params  []interface{} `json:"params"`
-		ID      uint64        `json:"id"`
+	return unmarshalResult(method, resp.Result, result)
+}
+
+// BatchCall sends all elements in one HTTP request. Returned error means the whole
+// batch failed, errors of single calls are set to BatchElem.Error.
+func (r *Http) BatchCall(ctx context.Context, elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
 	}
 
-	rpcResponse struct {
-		JSONRPC string          `json:"jsonrpc"`
-		ID      int             `json:"id"`
-		Result  json.RawMessage `json:"result"`
-		Error   *rpcError       `json:"error,omitempty"`
+	requests := make([]rpcRequest, len(elems))
+	ids := make([]uint64, len(elems))
+	for i, elem := range elems {
+		ids[i] = atomic.AddUint64(&r.idCounter, 1)
+		requests[i] = newRPCRequest(ids[i], elem.Method, elem.Params)
 	}
 
-	rpcError struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
+	body, err := r.post(ctx, methodBatch, requests)
+	if err != nil {
+		return fmt.Errorf("error during batch request: %w", err)
 	}
 
-	Http struct {
-		client *http.Client
+	// server answers with a single object when the whole batch is rejected
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var rpcResp rpcResponse
+		if err := json.Unmarshal(trimmed, &rpcResp); err == nil && rpcResp.Error != nil {
+			return newResponseError(methodBatch, rpcResp.Error)
+		}
 
-		idCounter uint64
-		url       string
+		return ErrRPCResponseError
 	}
-)
 
-var (
-	ErrEthereumServerUnavailable = errors.New("ethereum server is unavailable")
-	ErrRPCResponseError          = errors.New("rpc error is returned")
-)
-
-func NewHttp(
-	client *http.Client,
-	url string,
-) *Http {
-	return &Http{
-		client:    client,
-		idCounter: 0,
-		url:       url,
+	var responses []rpcResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return fmt.Errorf("error unmarshaling batch response: %w", err)
 	}
+
+	dispatchBatch(elems, ids, responses)
+
+	return nil
 }
 
-func (r *Http) GetBlockByNumber(ctx context.Context, number string) (*Block, error) {
-	reqBody := rpcRequest{
-		JSONRPC: rpcVersion,
-		Method:  methodGetBlockByNumber,
-		Params:  []interface{}{number, true},
-		ID:      atomic.AddUint64(&r.idCounter, 1),
+func (r *Http) sendRequest(ctx context.Context, reqBody *rpcRequest) (*rpcResponse, error) {
+	body, err := r.post(ctx, reqBody.Method, reqBody)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := r.sendRequest(ctx, &reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("error during %s request: %w", methodGetBlockByNumber, err)
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %w", err)
 	}
 
-	var block Block
-	if err := json.Unmarshal(resp.Result, &block); err != nil {
-		return nil, fmt.Errorf("error unmarshaling block: %w", err)
+	if rpcResp.Error != nil {
+		return nil, newResponseError(reqBody.Method, rpcResp.Error)
 	}
 
-	return &block, nil
+	return &rpcResp, nil
 }
 
-func (r *Http) sendRequest(ctx context.Context, reqBody *rpcRequest) (*rpcResponse, error) {
-	reqBytes, err := json.Marshal(reqBody)
+func (r *Http) post(ctx context.Context, method string, payload interface{}) ([]byte, error) {
+	reqBytes, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("error encoding request: %w", err)
 	}
@@ -112,12 +116,16 @@ func (r *Http) sendRequest(ctx context.Context, reqBody *rpcRequest) (*rpcRespon
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, fmt.Errorf("%w: error sending request: %w", ErrEthereumServerUnavailable, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, ErrEthereumServerUnavailable
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(method, resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -125,14 +133,5 @@ func (r *Http) sendRequest(ctx context.Context, reqBody *rpcRequest) (*rpcRespon
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
-	var rpcResp rpcResponse
-	if err := json.Unmarshal(body, &rpcResp); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
-	}
-
-	if rpcResp.Error != nil {
-		return nil, ErrRPCResponseError
-	}
-
-	return &rpcResp, nil
+	return body, nil
 }
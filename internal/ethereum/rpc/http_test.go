@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -142,7 +143,18 @@ func TestRpcGetBlockByNumber(t *testing.T) {
 	if assert.NoError(t, err) {
 		if assert.NotNil(t, block) {
 			assert.Equal(t, blockNumber, block.Number)
-			assert.Len(t, block.Transactions, 2)
+			assert.Equal(t, "0x66e88f3b", block.Timestamp)
+			if assert.Len(t, block.Transactions, 2) {
+				tx := block.Transactions[1]
+				assert.Equal(t, "0x3b5", tx.Nonce)
+				assert.Equal(t, "0x6a021", tx.Gas)
+				assert.Equal(t, "0x1885c0398", tx.GasPrice)
+				assert.Equal(t, "0x2b1a06a7e", tx.MaxFeePerGas)
+				assert.Equal(t, "0xc18b1a", tx.MaxPriorityFeePerGas)
+				assert.Equal(t, "0x2", tx.Type)
+				assert.Equal(t, "0x1", tx.ChainID)
+				assert.Equal(t, "0x1", tx.TransactionIndex)
+			}
 		}
 	}
 }
@@ -165,6 +177,12 @@ func TestRpcGetBlockByNumberServerUnavailable(t *testing.T) {
 	// assert
 	assert.ErrorIs(t, err, rpc.ErrEthereumServerUnavailable)
 	assert.Nil(t, block)
+
+	var rpcErr *rpc.Error
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, http.StatusInternalServerError, rpcErr.HTTPStatus)
+		assert.Equal(t, "eth_getBlockByNumber", rpcErr.Method)
+	}
 }
 
 func TestRpcGetBlockByNumberMailformedJson(t *testing.T) {
@@ -207,4 +225,271 @@ func TestRpcGetBlockByNumberRpcError(t *testing.T) {
 	// assert
 	assert.ErrorIs(t, err, rpc.ErrRPCResponseError)
 	assert.Nil(t, block)
+
+	var rpcErr *rpc.Error
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, 1234, rpcErr.Code)
+		assert.Equal(t, "error happened", rpcErr.Message)
+		assert.Equal(t, "eth_getBlockByNumber", rpcErr.Method)
+	}
+}
+
+func TestRpcGetBlockByNumberNullResult(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": null}`))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	block, err := client.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrBlockNotFound)
+	assert.True(t, rpc.IsBlockNotFound(err))
+	assert.Nil(t, block)
+}
+
+func newBatchServer(t *testing.T, failedNumber string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var requests []map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(body, &requests)) {
+			return
+		}
+
+		responses := make([]map[string]interface{}, 0, len(requests))
+		for _, request := range requests {
+			number := request["params"].([]interface{})[0].(string)
+
+			response := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      request["id"],
+			}
+			if number == failedNumber {
+				response["error"] = map[string]interface{}{"code": -32000, "message": "failed"}
+			} else {
+				response["result"] = map[string]interface{}{"number": number}
+			}
+			responses = append(responses, response)
+		}
+
+		// responses may come in any order
+		slices.Reverse(responses)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(responses)
+	}))
+}
+
+func TestRpcGetBlocksByNumber(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := newBatchServer(t, "")
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	blocks, err := client.GetBlocksByNumber(context.Background(), []string{"0x1", "0x2", "0x3"})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, blocks, 3) {
+		assert.Equal(t, "0x1", blocks[0].Number)
+		assert.Equal(t, "0x2", blocks[1].Number)
+		assert.Equal(t, "0x3", blocks[2].Number)
+	}
+}
+
+func TestRpcGetBlocksByNumberItemError(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := newBatchServer(t, "0x2")
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	blocks, err := client.GetBlocksByNumber(context.Background(), []string{"0x1", "0x2"})
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrRPCResponseError)
+	assert.Nil(t, blocks)
+}
+
+func TestRpcBatchCallPerItemErrors(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := newBatchServer(t, "0x2")
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	var block1, block2 rpc.Block
+	elems := []rpc.BatchElem{
+		{Method: "eth_getBlockByNumber", Params: []interface{}{"0x1", true}, Result: &block1},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{"0x2", true}, Result: &block2},
+	}
+
+	// act
+	err := client.BatchCall(context.Background(), elems)
+
+	// assert
+	assert.NoError(t, err)
+	assert.NoError(t, elems[0].Error)
+	assert.Equal(t, "0x1", block1.Number)
+	assert.ErrorIs(t, elems[1].Error, rpc.ErrRPCResponseError)
+}
+
+func TestRpcBatchCallRejected(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(rpcErrorMessage))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	err := client.BatchCall(context.Background(), []rpc.BatchElem{{Method: "eth_blockNumber"}})
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrRPCResponseError)
+}
+
+func TestRpcGetBlockReceipts(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		assert.Equal(t, "eth_getBlockReceipts", request["method"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": [
+			{"transactionHash": "0xa", "status": "0x0", "gasUsed": "0x5208", "effectiveGasPrice": "0x1", "contractAddress": null}
+		]}`))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	receipts, err := client.GetBlockReceipts(context.Background(), "0x1")
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, receipts, 1) {
+		assert.Equal(t, "0xa", receipts[0].TransactionHash)
+		assert.Equal(t, rpc.ReceiptStatusFailed, receipts[0].Status)
+		assert.Equal(t, "0x5208", receipts[0].GasUsed)
+		assert.Empty(t, receipts[0].ContractAddress)
+	}
+}
+
+func TestRpcGetTransactionReceiptNotFound(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": null}`))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	receipt, err := client.GetTransactionReceipt(context.Background(), "0xa")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrReceiptNotFound)
+	assert.Nil(t, receipt)
+}
+
+func TestRpcGetLogs(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		assert.Equal(t, "eth_getLogs", request["method"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"blockHash": "0xb",
+				"topics":    []interface{}{[]interface{}{"0xt"}},
+			},
+		}, request["params"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": [
+			{"address": "0xc", "topics": ["0xt"], "data": "0x", "blockHash": "0xb", "transactionHash": "0xa", "logIndex": "0x3", "removed": false}
+		]}`))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	logs, err := client.GetLogs(context.Background(), rpc.LogFilter{
+		BlockHash: "0xb",
+		Topics:    [][]string{{"0xt"}},
+	})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, logs, 1) {
+		assert.Equal(t, "0xc", logs[0].Address)
+		assert.Equal(t, "0xa", logs[0].TransactionHash)
+		assert.Equal(t, "0x3", logs[0].LogIndex)
+	}
+}
+
+func TestRpcTraceBlockByNumber(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		assert.Equal(t, "debug_traceBlockByNumber", request["method"])
+		assert.Equal(t, []interface{}{"0x1", map[string]interface{}{"tracer": "callTracer"}}, request["params"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": [
+			{"txHash": "0xa", "result": {"type": "CALL", "from": "0x1", "to": "0x2", "value": "0x0", "calls": [
+				{"type": "CALL", "from": "0x2", "to": "0x3", "value": "0x5"}
+			]}}
+		]}`))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewHttp(&http.Client{}, server.URL)
+
+	// act
+	traces, err := client.TraceBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, traces, 1) {
+		assert.Equal(t, "0xa", traces[0].TxHash)
+		if assert.Len(t, traces[0].Result.Calls, 1) {
+			assert.Equal(t, "0x3", traces[0].Result.Calls[0].To)
+			assert.Equal(t, "0x5", traces[0].Result.Calls[0].Value)
+		}
+	}
 }
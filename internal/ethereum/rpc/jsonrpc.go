@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	rpcVersion = "2.0"
+
+	// methodBatch names batch request in errors which are not related to a single call
+	methodBatch = "batch"
+)
+
+type (
+	rpcRequest struct {
+		JSONRPC string        `json:"jsonrpc"`
+		Method  string        `json:"method"`
+		Params  []interface{} `json:"params"`
+		ID      uint64        `json:"id"`
+	}
+
+	rpcResponse struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      uint64          `json:"id"`
+		Result  json.RawMessage `json:"result"`
+		Error   *rpcError       `json:"error,omitempty"`
+	}
+
+	rpcError struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data,omitempty"`
+	}
+
+	// BatchElem is a single call of JSON-RPC batch request.
+	BatchElem struct {
+		Method string
+		Params []interface{}
+		// Result is a pointer where call result is unmarshaled.
+		Result interface{}
+		// Error is set when this call failed while the batch itself succeeded.
+		Error error
+	}
+)
+
+var (
+	ErrEthereumServerUnavailable = errors.New("ethereum server is unavailable")
+	ErrRPCResponseError          = errors.New("rpc error is returned")
+	ErrBatchResponseMissing      = errors.New("batch response is missing")
+)
+
+func newRPCRequest(id uint64, method string, params []interface{}) rpcRequest {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	return rpcRequest{
+		JSONRPC: rpcVersion,
+		Method:  method,
+		Params:  params,
+		ID:      id,
+	}
+}
+
+// unmarshalResult decodes response result of method, nil result discards it.
+func unmarshalResult(method string, raw json.RawMessage, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("error unmarshaling %s result: %w", method, err)
+	}
+
+	return nil
+}
+
+// dispatchBatch matches batch responses to elements by id, elements without response get ErrBatchResponseMissing.
+func dispatchBatch(elems []BatchElem, ids []uint64, responses []rpcResponse) {
+	indexByID := make(map[uint64]int, len(ids))
+	for i, id := range ids {
+		indexByID[id] = i
+	}
+
+	answered := make([]bool, len(elems))
+	for _, resp := range responses {
+		i, ok := indexByID[resp.ID]
+		if !ok {
+			continue
+		}
+		answered[i] = true
+
+		if resp.Error != nil {
+			elems[i].Error = newResponseError(elems[i].Method, resp.Error)
+			continue
+		}
+
+		elems[i].Error = unmarshalResult(elems[i].Method, resp.Result, elems[i].Result)
+	}
+
+	for i := range elems {
+		if !answered[i] {
+			elems[i].Error = ErrBatchResponseMissing
+		}
+	}
+}
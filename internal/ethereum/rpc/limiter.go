@@ -0,0 +1,140 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	RateLimitConfig struct {
+		// RequestsPerSecond is the sustained rate, zero or less disables limiting.
+		RequestsPerSecond float64
+		// Burst is the number of requests which may be sent at once after idle time, one by default.
+		Burst int
+	}
+
+	// RateLimitStats shows how much callers were slowed down by the limiter.
+	RateLimitStats struct {
+		Requests uint64
+		// Waited is the number of calls which had to wait for a token.
+		Waited   uint64
+		WaitTime time.Duration
+	}
+
+	// RateLimiter passes calls to the wrapped Caller at limited rate using token bucket,
+	// it is shared by every goroutine using it. Typed methods come from embedded Client.
+	RateLimiter struct {
+		*Client
+
+		caller Caller
+		config RateLimitConfig
+
+		mu     sync.Mutex
+		tokens float64
+		last   time.Time
+		stats  RateLimitStats
+	}
+)
+
+func NewRateLimiter(caller Caller, config RateLimitConfig) *RateLimiter {
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+
+	r := &RateLimiter{
+		caller: caller,
+		config: config,
+		tokens: float64(config.Burst),
+		last:   time.Now(),
+	}
+	r.Client = NewClient(r)
+
+	return r
+}
+
+func (r *RateLimiter) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if err := r.Wait(ctx, 1); err != nil {
+		return err
+	}
+
+	return r.caller.Call(ctx, result, method, params...)
+}
+
+// BatchCall takes a token for every call in the batch, as nodes count them separately.
+func (r *RateLimiter) BatchCall(ctx context.Context, elems []BatchElem) error {
+	if err := r.Wait(ctx, len(elems)); err != nil {
+		return err
+	}
+
+	return r.caller.BatchCall(ctx, elems)
+}
+
+// Wait blocks until n tokens are available or ctx is done. Tokens are reserved in order of calls,
+// so n larger than burst waits for the deficit instead of failing.
+func (r *RateLimiter) Wait(ctx context.Context, n int) error {
+	if r.config.RequestsPerSecond <= 0 || n <= 0 {
+		return nil
+	}
+
+	delay := r.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"tokens": n,
+			"delay":  delay,
+		}).
+		Debug("RPC call is delayed by rate limiter")
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		r.cancel(n)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *RateLimiter) Stats() RateLimitStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
+
+func (r *RateLimiter) reserve(n int) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens = min(r.tokens+now.Sub(r.last).Seconds()*r.config.RequestsPerSecond, float64(r.config.Burst))
+	r.last = now
+	r.tokens -= float64(n)
+	r.stats.Requests++
+
+	if r.tokens >= 0 {
+		return 0
+	}
+
+	delay := time.Duration(-r.tokens / r.config.RequestsPerSecond * float64(time.Second))
+	r.stats.Waited++
+	r.stats.WaitTime += delay
+
+	return delay
+}
+
+// cancel returns tokens of a call which gave up waiting.
+func (r *RateLimiter) cancel(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = min(r.tokens+float64(n), float64(r.config.Burst))
+}
@@ -0,0 +1,117 @@
+package rpc_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/rpc"
+)
+
+// countingCaller succeeds every call and counts them.
+type countingCaller struct {
+	calls int32
+}
+
+func (c *countingCaller) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	atomic.AddInt32(&c.calls, 1)
+	return nil
+}
+
+func (c *countingCaller) BatchCall(ctx context.Context, elems []rpc.BatchElem) error {
+	atomic.AddInt32(&c.calls, int32(len(elems)))
+	return nil
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	// arrange
+	caller := &countingCaller{}
+	limiter := rpc.NewRateLimiter(caller, rpc.RateLimitConfig{RequestsPerSecond: 1, Burst: 3})
+
+	// act
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.Call(context.Background(), nil, "eth_blockNumber"))
+	}
+
+	// assert
+	assert.Equal(t, int32(3), atomic.LoadInt32(&caller.calls))
+	assert.Equal(t, rpc.RateLimitStats{Requests: 3}, limiter.Stats())
+}
+
+func TestRateLimiterSharedRate(t *testing.T) {
+	// arrange
+	caller := &countingCaller{}
+	limiter := rpc.NewRateLimiter(caller, rpc.RateLimitConfig{RequestsPerSecond: 100, Burst: 1})
+	start := time.Now()
+
+	// act
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, limiter.Call(context.Background(), nil, "eth_blockNumber"))
+		}()
+	}
+	wg.Wait()
+
+	// assert
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+	assert.Equal(t, int32(10), atomic.LoadInt32(&caller.calls))
+
+	stats := limiter.Stats()
+	assert.Equal(t, uint64(10), stats.Requests)
+	assert.Equal(t, uint64(9), stats.Waited)
+	assert.Greater(t, stats.WaitTime, time.Duration(0))
+}
+
+func TestRateLimiterBatchLargerThanBurst(t *testing.T) {
+	// arrange
+	caller := &countingCaller{}
+	limiter := rpc.NewRateLimiter(caller, rpc.RateLimitConfig{RequestsPerSecond: 100, Burst: 2})
+	start := time.Now()
+
+	// act
+	err := limiter.BatchCall(context.Background(), make([]rpc.BatchElem, 4))
+
+	// assert
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&caller.calls))
+}
+
+func TestRateLimiterContextCanceled(t *testing.T) {
+	// arrange
+	caller := &countingCaller{}
+	limiter := rpc.NewRateLimiter(caller, rpc.RateLimitConfig{RequestsPerSecond: 0.1, Burst: 1})
+	assert.NoError(t, limiter.Call(context.Background(), nil, "eth_blockNumber"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// act
+	err := limiter.Call(ctx, nil, "eth_blockNumber")
+
+	// assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&caller.calls))
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	// arrange
+	caller := &countingCaller{}
+	limiter := rpc.NewRateLimiter(caller, rpc.RateLimitConfig{})
+
+	// act
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, limiter.Call(context.Background(), nil, "eth_blockNumber"))
+	}
+
+	// assert
+	assert.Equal(t, int32(100), atomic.LoadInt32(&caller.calls))
+	assert.Equal(t, uint64(0), limiter.Stats().Waited)
+}
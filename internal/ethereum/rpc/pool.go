@@ -0,0 +1,326 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultPoolMaxLag            = 5
+	DefaultPoolHeadCheckInterval = 15 * time.Second
+	DefaultPoolMaxFailures       = 3
+	DefaultPoolFailureCooldown   = 30 * time.Second
+	poolStatsWeight              = 0.2
+	poolErrorRatePenalty         = 10
+	methodBlockNumber            = "eth_blockNumber"
+)
+
+type (
+	PoolEndpoint struct {
+		URL    string
+		Caller Caller
+	}
+
+	PoolConfig struct {
+		// MaxLag is the number of blocks endpoint may be behind the best head before it is taken out of rotation.
+		MaxLag int
+		// HeadCheckInterval is the period of head block checks made by WatchHeads.
+		HeadCheckInterval time.Duration
+		// MaxFailures is the number of consecutive failures after which endpoint is taken out of rotation.
+		MaxFailures int
+		// FailureCooldown is the time failed endpoint stays out of rotation.
+		FailureCooldown time.Duration
+	}
+
+	// EndpointHealth is a snapshot of endpoint statistics.
+	EndpointHealth struct {
+		URL string
+		// Latency and ErrorRate are exponentially weighted averages of recent calls.
+		Latency   time.Duration
+		ErrorRate float64
+		Head      int
+		Lagging   bool
+		Available bool
+	}
+
+	poolEndpoint struct {
+		PoolEndpoint
+
+		latency       time.Duration
+		errorRate     float64
+		succeeded     bool
+		head          int
+		failures      int
+		cooldownUntil time.Time
+	}
+
+	// Pool routes calls to the healthiest of several endpoints and fails over to the next one
+	// when the call fails because of the endpoint. Typed methods come from embedded Client.
+	Pool struct {
+		*Client
+
+		endpoints []*poolEndpoint
+		config    PoolConfig
+
+		mu sync.Mutex
+	}
+)
+
+var ErrNoEndpoints = errors.New("rpc pool has no endpoints")
+
+func NewPool(endpoints []PoolEndpoint, config PoolConfig) *Pool {
+	if config.MaxLag <= 0 {
+		config.MaxLag = DefaultPoolMaxLag
+	}
+	if config.HeadCheckInterval <= 0 {
+		config.HeadCheckInterval = DefaultPoolHeadCheckInterval
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = DefaultPoolMaxFailures
+	}
+	if config.FailureCooldown <= 0 {
+		config.FailureCooldown = DefaultPoolFailureCooldown
+	}
+
+	p := &Pool{
+		config: config,
+	}
+	for _, endpoint := range endpoints {
+		p.endpoints = append(p.endpoints, &poolEndpoint{PoolEndpoint: endpoint})
+	}
+	p.Client = NewClient(p)
+
+	return p
+}
+
+func (p *Pool) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	return p.do(ctx, method, func(caller Caller) error {
+		return caller.Call(ctx, result, method, params...)
+	})
+}
+
+func (p *Pool) BatchCall(ctx context.Context, elems []BatchElem) error {
+	return p.do(ctx, methodBatch, func(caller Caller) error {
+		for i := range elems {
+			elems[i].Error = nil
+		}
+
+		return caller.BatchCall(ctx, elems)
+	})
+}
+
+// CheckHeads asks every endpoint for its head block, endpoints behind the best one are out of rotation until they catch up.
+func (p *Pool) CheckHeads(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, endpoint := range p.endpoints {
+		wg.Add(1)
+		go func(endpoint *poolEndpoint) {
+			defer wg.Done()
+
+			var value string
+			started := time.Now()
+			err := endpoint.Caller.Call(ctx, &value, methodBlockNumber)
+			p.record(endpoint, time.Since(started), err)
+			if err != nil {
+				return
+			}
+
+			head, err := strconv.ParseInt(strings.TrimPrefix(value, "0x"), 16, 0)
+			if err != nil {
+				logrus.
+					WithFields(logrus.Fields{
+						"url":          endpoint.URL,
+						"block_number": value,
+					}).
+					WithError(err).
+					Warn("failed to parse endpoint head block")
+
+				return
+			}
+
+			p.mu.Lock()
+			endpoint.head = int(head)
+			p.mu.Unlock()
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// WatchHeads checks endpoint heads every HeadCheckInterval until ctx is done.
+func (p *Pool) WatchHeads(ctx context.Context) {
+	ticker := time.NewTicker(p.config.HeadCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.CheckHeads(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Health returns statistics of endpoints in the order they were given.
+func (p *Pool) Health() []EndpointHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	bestHead := p.bestHead()
+
+	health := make([]EndpointHealth, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		lagging := p.lagging(endpoint, bestHead)
+		health = append(health, EndpointHealth{
+			URL:       endpoint.URL,
+			Latency:   endpoint.latency,
+			ErrorRate: endpoint.errorRate,
+			Head:      endpoint.head,
+			Lagging:   lagging,
+			Available: !lagging && now.After(endpoint.cooldownUntil),
+		})
+	}
+
+	return health
+}
+
+// do tries endpoints from the healthiest one until the call succeeds or fails for reason unrelated to endpoint.
+func (p *Pool) do(ctx context.Context, method string, call func(caller Caller) error) error {
+	endpoints := p.rotation()
+	if len(endpoints) == 0 {
+		return ErrNoEndpoints
+	}
+
+	var err error
+	for _, endpoint := range endpoints {
+		started := time.Now()
+		err = call(endpoint.Caller)
+		if ctx.Err() != nil {
+			return err
+		}
+
+		// rejected call or unknown block is not a failure of the node itself,
+		// though a lagging node may not know the block yet, so the next one is asked
+		retryable := IsRetryable(err)
+		if retryable {
+			p.record(endpoint, time.Since(started), err)
+		} else {
+			p.record(endpoint, time.Since(started), nil)
+		}
+
+		if !retryable && !IsBlockNotFound(err) {
+			return err
+		}
+
+		logrus.
+			WithFields(logrus.Fields{
+				"url":    endpoint.URL,
+				"method": method,
+			}).
+			WithError(err).
+			Warn("RPC call failed, trying next endpoint")
+	}
+
+	return fmt.Errorf("all %d endpoints failed: %w", len(endpoints), err)
+}
+
+// rotation returns available endpoints ordered by score, when none is available all endpoints are returned.
+func (p *Pool) rotation() []*poolEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	bestHead := p.bestHead()
+
+	available := make([]*poolEndpoint, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		if !p.lagging(endpoint, bestHead) && now.After(endpoint.cooldownUntil) {
+			available = append(available, endpoint)
+		}
+	}
+	if len(available) == 0 {
+		available = append(available, p.endpoints...)
+	}
+
+	slices.SortStableFunc(available, func(a, b *poolEndpoint) int {
+		scoreA, scoreB := a.score(), b.score()
+		switch {
+		case scoreA < scoreB:
+			return -1
+		case scoreA > scoreB:
+			return 1
+		}
+
+		return 0
+	})
+
+	return available
+}
+
+func (p *Pool) record(endpoint *poolEndpoint, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		endpoint.errorRate += poolStatsWeight * (1 - endpoint.errorRate)
+		endpoint.failures++
+		if endpoint.failures >= p.config.MaxFailures {
+			endpoint.cooldownUntil = time.Now().Add(p.config.FailureCooldown)
+
+			logrus.
+				WithFields(logrus.Fields{
+					"url":      endpoint.URL,
+					"failures": endpoint.failures,
+					"cooldown": p.config.FailureCooldown,
+				}).
+				WithError(err).
+				Warn("Endpoint is taken out of rotation")
+		}
+
+		return
+	}
+
+	endpoint.errorRate -= poolStatsWeight * endpoint.errorRate
+	endpoint.failures = 0
+	if !endpoint.succeeded {
+		endpoint.succeeded = true
+		endpoint.latency = latency
+	} else {
+		endpoint.latency += time.Duration(poolStatsWeight * float64(latency-endpoint.latency))
+	}
+}
+
+func (p *Pool) bestHead() int {
+	best := 0
+	for _, endpoint := range p.endpoints {
+		best = max(best, endpoint.head)
+	}
+
+	return best
+}
+
+func (p *Pool) lagging(endpoint *poolEndpoint, bestHead int) bool {
+	return endpoint.head > 0 && bestHead-endpoint.head > p.config.MaxLag
+}
+
+// score is lower for faster and more reliable endpoints. Latency is known only from successful calls,
+// so endpoint without them, not called yet or only failing, has the worst score and does not come
+// before healthy ones because its failures are fast. Such endpoints keep the order they were given.
+func (e *poolEndpoint) score() float64 {
+	if !e.succeeded {
+		return math.Inf(1)
+	}
+
+	return float64(max(e.latency, time.Millisecond)) * (1 + poolErrorRatePenalty*e.errorRate)
+}
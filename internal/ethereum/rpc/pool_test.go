@@ -0,0 +1,230 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/rpc"
+)
+
+type poolTestServer struct {
+	*httptest.Server
+
+	head     int
+	status   int
+	delay    time.Duration
+	requests int32
+}
+
+// newPoolTestServer answers eth_blockNumber with head and blocks with their number, non-zero status fails every request.
+func newPoolTestServer(head int, status int) *poolTestServer {
+	s := &poolTestServer{
+		head:   head,
+		status: status,
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		time.Sleep(s.delay)
+
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			return
+		}
+
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		var result interface{}
+		switch request["method"] {
+		case "eth_blockNumber":
+			result = fmt.Sprintf("0x%x", s.head)
+		case "eth_getBlockByNumber":
+			result = map[string]interface{}{"number": request["params"].([]interface{})[0], "hash": s.URL}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request["id"],
+			"result":  result,
+		})
+	}))
+
+	return s
+}
+
+func newTestPool(config rpc.PoolConfig, servers ...*poolTestServer) *rpc.Pool {
+	endpoints := make([]rpc.PoolEndpoint, 0, len(servers))
+	for _, server := range servers {
+		endpoints = append(endpoints, rpc.PoolEndpoint{
+			URL:    server.URL,
+			Caller: rpc.NewHttp(&http.Client{}, server.URL),
+		})
+	}
+
+	return rpc.NewPool(endpoints, config)
+}
+
+func TestPoolFailover(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	failing := newPoolTestServer(10, http.StatusBadGateway)
+	defer failing.Close()
+	healthy := newPoolTestServer(10, 0)
+	defer healthy.Close()
+
+	// arrange
+	pool := newTestPool(rpc.PoolConfig{}, failing, healthy)
+
+	// act
+	block, err := pool.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, healthy.URL, block.Hash)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failing.requests))
+}
+
+func TestPoolPrefersHealthyEndpoint(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	failing := newPoolTestServer(10, http.StatusBadGateway)
+	defer failing.Close()
+	healthy := newPoolTestServer(10, 0)
+	defer healthy.Close()
+
+	// arrange
+	pool := newTestPool(rpc.PoolConfig{MaxFailures: 1}, failing, healthy)
+
+	// act
+	for i := 0; i < 5; i++ {
+		_, err := pool.GetBlockByNumber(context.Background(), "0x1")
+		assert.NoError(t, err)
+	}
+
+	// assert
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failing.requests))
+
+	health := pool.Health()
+	if assert.Len(t, health, 2) {
+		assert.False(t, health[0].Available)
+		assert.Greater(t, health[0].ErrorRate, 0.0)
+		assert.True(t, health[1].Available)
+	}
+}
+
+func TestPoolPrefersSlowHealthyEndpointToFastFailing(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	failing := newPoolTestServer(10, http.StatusBadGateway)
+	defer failing.Close()
+	healthy := newPoolTestServer(10, 0)
+	healthy.delay = 20 * time.Millisecond
+	defer healthy.Close()
+
+	// arrange
+	pool := newTestPool(rpc.PoolConfig{}, failing, healthy)
+
+	// act
+	for i := 0; i < 5; i++ {
+		_, err := pool.GetBlockByNumber(context.Background(), "0x1")
+		assert.NoError(t, err)
+	}
+
+	// assert
+	// failing endpoint is below cooldown threshold, it is not asked first only because of its score
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failing.requests))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&healthy.requests))
+}
+
+func TestPoolLaggingEndpoint(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	lagging := newPoolTestServer(10, 0)
+	defer lagging.Close()
+	synced := newPoolTestServer(20, 0)
+	defer synced.Close()
+
+	// arrange
+	pool := newTestPool(rpc.PoolConfig{MaxLag: 5}, lagging, synced)
+	pool.CheckHeads(context.Background())
+
+	// act
+	block, err := pool.GetBlockByNumber(context.Background(), "latest")
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, synced.URL, block.Hash)
+	}
+
+	health := pool.Health()
+	if assert.Len(t, health, 2) {
+		assert.True(t, health[0].Lagging)
+		assert.Equal(t, 10, health[0].Head)
+		assert.False(t, health[1].Lagging)
+		assert.Equal(t, 20, health[1].Head)
+	}
+}
+
+func TestPoolAllEndpointsFailed(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	first := newPoolTestServer(10, http.StatusServiceUnavailable)
+	defer first.Close()
+	second := newPoolTestServer(10, http.StatusBadGateway)
+	defer second.Close()
+
+	// arrange
+	pool := newTestPool(rpc.PoolConfig{}, first, second)
+
+	// act
+	block, err := pool.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrEthereumServerUnavailable)
+	assert.Nil(t, block)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&first.requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&second.requests))
+}
+
+func TestPoolNotRetryableError(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	rejecting := newPoolTestServer(10, http.StatusBadRequest)
+	defer rejecting.Close()
+	healthy := newPoolTestServer(10, 0)
+	defer healthy.Close()
+
+	// arrange
+	pool := newTestPool(rpc.PoolConfig{}, rejecting, healthy)
+
+	// act
+	_, err := pool.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&healthy.requests))
+}
+
+func TestPoolNoEndpoints(t *testing.T) {
+	// arrange
+	pool := rpc.NewPool(nil, rpc.PoolConfig{})
+
+	// act
+	_, err := pool.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrNoEndpoints)
+}
@@ -0,0 +1,520 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// BlockReader is implemented by Client and every client embedding it.
+	BlockReader interface {
+		GetBlockByNumber(ctx context.Context, number string) (*Block, error)
+		GetBlocksByNumber(ctx context.Context, numbers []string) ([]*Block, error)
+	}
+
+	// ReceiptReader is implemented by Client and every client embedding it.
+	ReceiptReader interface {
+		GetTransactionReceipts(ctx context.Context, hashes []string) ([]*Receipt, error)
+		GetBlockReceipts(ctx context.Context, number string) ([]*Receipt, error)
+	}
+
+	// LogReader is implemented by Client and every client embedding it.
+	LogReader interface {
+		GetLogs(ctx context.Context, filter LogFilter) ([]*Log, error)
+	}
+
+	// TraceReader is implemented by Client and every client embedding it.
+	TraceReader interface {
+		TraceBlockByNumber(ctx context.Context, number string) ([]*TransactionTrace, error)
+		TraceBlock(ctx context.Context, number string) ([]*Trace, error)
+	}
+
+	QuorumClient interface {
+		BlockReader
+		ReceiptReader
+		LogReader
+		TraceReader
+	}
+
+	QuorumProvider struct {
+		URL    string
+		Client QuorumClient
+	}
+
+	QuorumConfig struct {
+		// Threshold is the number of providers which must return the same block, majority by default.
+		// It can not exceed the number of providers.
+		Threshold int
+	}
+
+	// Quorum returns blocks and receipts only when enough providers agree on them. Errors rejecting
+	// the call, e.g. of a method which is not supported, are returned when enough providers agree on them.
+	Quorum struct {
+		providers []QuorumProvider
+		config    QuorumConfig
+
+		mu          sync.Mutex
+		divergences map[string]int
+	}
+
+	quorumAnswer[T any] struct {
+		provider QuorumProvider
+		values   []T
+		err      error
+	}
+)
+
+var (
+	ErrQuorumNotReached       = errors.New("providers did not agree on result")
+	ErrInvalidQuorumThreshold = errors.New("invalid quorum threshold")
+)
+
+// NewQuorum fails when threshold can not be reached by the given providers, zero threshold means majority.
+func NewQuorum(providers []QuorumProvider, config QuorumConfig) (*Quorum, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("error creating quorum: %w", ErrNoEndpoints)
+	}
+	if config.Threshold == 0 {
+		config.Threshold = len(providers)/2 + 1
+	}
+	if config.Threshold < 0 || config.Threshold > len(providers) {
+		return nil, fmt.Errorf("error creating quorum: %w %d of %d providers", ErrInvalidQuorumThreshold, config.Threshold, len(providers))
+	}
+
+	return &Quorum{
+		providers:   providers,
+		config:      config,
+		divergences: make(map[string]int),
+	}, nil
+}
+
+// GetBlockByNumber resolves latest to the highest block which at least Threshold providers reached.
+func (q *Quorum) GetBlockByNumber(ctx context.Context, number string) (*Block, error) {
+	if number == NumberLatest {
+		resolved, err := q.latestNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		number = resolved
+	}
+
+	blocks, err := q.GetBlocksByNumber(ctx, []string{number})
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks[0], nil
+}
+
+func (q *Quorum) GetBlocksByNumber(ctx context.Context, numbers []string) ([]*Block, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([]*Block, error) {
+		if len(numbers) == 1 {
+			block, err := client.GetBlockByNumber(ctx, numbers[0])
+			return []*Block{block}, err
+		}
+
+		return client.GetBlocksByNumber(ctx, numbers)
+	})
+
+	blocks := make([]*Block, len(numbers))
+	for i, number := range numbers {
+		block, err := agreeQuorum(q, answers, i, blockFingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("error getting block %s: %w", number, err)
+		}
+		blocks[i] = block
+	}
+
+	return blocks, nil
+}
+
+func (q *Quorum) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*Receipt, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([]*Receipt, error) {
+		return client.GetTransactionReceipts(ctx, hashes)
+	})
+
+	receipts := make([]*Receipt, len(hashes))
+	for i, hash := range hashes {
+		receipt, err := agreeQuorum(q, answers, i, receiptFingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("error getting receipt of transaction %s: %w", hash, err)
+		}
+		receipts[i] = receipt
+	}
+
+	return receipts, nil
+}
+
+func (q *Quorum) GetBlockReceipts(ctx context.Context, number string) ([]*Receipt, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([][]*Receipt, error) {
+		receipts, err := client.GetBlockReceipts(ctx, number)
+		return [][]*Receipt{receipts}, err
+	})
+
+	receipts, err := agreeQuorum(q, answers, 0, func(receipts []*Receipt) string {
+		if receipts == nil {
+			return ""
+		}
+
+		// block without transactions still has a fingerprint
+		var b strings.Builder
+		b.WriteString("receipts;")
+		for _, receipt := range receipts {
+			b.WriteString(receiptFingerprint(receipt))
+			b.WriteByte(';')
+		}
+
+		return b.String()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting receipts of block %s: %w", number, err)
+	}
+
+	return receipts, nil
+}
+
+func (q *Quorum) GetLogs(ctx context.Context, filter LogFilter) ([]*Log, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([][]*Log, error) {
+		logs, err := client.GetLogs(ctx, filter)
+		return [][]*Log{logs}, err
+	})
+
+	logs, err := agreeQuorum(q, answers, 0, func(logs []*Log) string {
+		if logs == nil {
+			return ""
+		}
+
+		var b strings.Builder
+		b.WriteString("logs;")
+		for _, log := range logs {
+			b.WriteString(logFingerprint(log))
+			b.WriteByte(';')
+		}
+
+		return b.String()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+func (q *Quorum) TraceBlockByNumber(ctx context.Context, number string) ([]*TransactionTrace, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([][]*TransactionTrace, error) {
+		traces, err := client.TraceBlockByNumber(ctx, number)
+		return [][]*TransactionTrace{traces}, err
+	})
+
+	traces, err := agreeQuorum(q, answers, 0, func(traces []*TransactionTrace) string {
+		if traces == nil {
+			return ""
+		}
+
+		var b strings.Builder
+		b.WriteString("traces;")
+		for _, trace := range traces {
+			b.WriteString(trace.TxHash)
+			b.WriteByte(':')
+			b.WriteString(trace.Error)
+			if trace.Result != nil {
+				writeCallFrameFingerprint(&b, trace.Result)
+			}
+			b.WriteByte(';')
+		}
+
+		return b.String()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting traces of block %s: %w", number, err)
+	}
+
+	return traces, nil
+}
+
+func (q *Quorum) TraceBlock(ctx context.Context, number string) ([]*Trace, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([][]*Trace, error) {
+		traces, err := client.TraceBlock(ctx, number)
+		return [][]*Trace{traces}, err
+	})
+
+	traces, err := agreeQuorum(q, answers, 0, func(traces []*Trace) string {
+		if traces == nil {
+			return ""
+		}
+
+		var b strings.Builder
+		b.WriteString("traces;")
+		for _, trace := range traces {
+			b.WriteString(traceFingerprint(trace))
+			b.WriteByte(';')
+		}
+
+		return b.String()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting traces of block %s: %w", number, err)
+	}
+
+	return traces, nil
+}
+
+// Divergences returns the number of answers on which every provider disagreed with quorum.
+func (q *Quorum) Divergences() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	divergences := make(map[string]int, len(q.divergences))
+	for url, count := range q.divergences {
+		divergences[url] = count
+	}
+
+	return divergences
+}
+
+func (q *Quorum) latestNumber(ctx context.Context) (string, error) {
+	answers := askQuorum(q, func(client QuorumClient) ([]*Block, error) {
+		block, err := client.GetBlockByNumber(ctx, NumberLatest)
+		return []*Block{block}, err
+	})
+
+	var heads []int64
+	for _, answer := range answers {
+		if answer.err != nil {
+			continue
+		}
+
+		head, err := strconv.ParseInt(strings.TrimPrefix(answer.values[0].Number, "0x"), 16, 0)
+		if err != nil {
+			continue
+		}
+		heads = append(heads, head)
+	}
+
+	if len(heads) < q.config.Threshold {
+		return "", fmt.Errorf("error getting latest block from %d of %d providers: %w", len(heads), q.config.Threshold, ErrQuorumNotReached)
+	}
+
+	slices.Sort(heads)
+	slices.Reverse(heads)
+
+	return fmt.Sprintf("0x%x", heads[q.config.Threshold-1]), nil
+}
+
+func (q *Quorum) reportDivergence(providers []QuorumProvider, errs []error, index int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, provider := range providers {
+		q.divergences[provider.URL]++
+
+		logrus.
+			WithFields(logrus.Fields{
+				"url":   provider.URL,
+				"index": index,
+			}).
+			WithError(errs[i]).
+			Warn("Provider diverged from quorum")
+	}
+}
+
+// askQuorum calls every provider in parallel.
+func askQuorum[T any](q *Quorum, call func(client QuorumClient) ([]T, error)) []quorumAnswer[T] {
+	answers := make([]quorumAnswer[T], len(q.providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range q.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			values, err := call(provider.Client)
+			answers[i] = quorumAnswer[T]{
+				provider: provider,
+				values:   values,
+				err:      err,
+			}
+		}()
+	}
+	wg.Wait()
+
+	return answers
+}
+
+// agreeQuorum returns value at index which at least Threshold providers returned, every other provider
+// is reported as divergent. Empty fingerprint means there is no value. When value is not agreed on,
+// but Threshold providers rejected the call the same way, e.g. because method is not supported,
+// their error is returned, so callers can fall back as they do with a single node.
+func agreeQuorum[T any](q *Quorum, answers []quorumAnswer[T], index int, fingerprint func(T) string) (T, error) {
+	var zero T
+
+	groups := make(map[string][]int)
+	errGroups := make(map[string][]int)
+	notFound := 0
+	var bestKey, bestErrKey string
+	for i, answer := range answers {
+		var key string
+		if answer.err == nil && index < len(answer.values) {
+			key = fingerprint(answer.values[index])
+		}
+		if key == "" {
+			if IsBlockNotFound(answer.err) {
+				notFound++
+			} else if errKey := quorumErrorKey(answer.err); errKey != "" {
+				errGroups[errKey] = append(errGroups[errKey], i)
+				if len(errGroups[errKey]) > len(errGroups[bestErrKey]) {
+					bestErrKey = errKey
+				}
+			}
+			continue
+		}
+
+		groups[key] = append(groups[key], i)
+		if len(groups[key]) > len(groups[bestKey]) {
+			bestKey = key
+		}
+	}
+
+	agreed := groups[bestKey]
+	reached := len(agreed) >= q.config.Threshold
+
+	errAgreed := errGroups[bestErrKey]
+	rejected := !reached && len(errAgreed) >= q.config.Threshold
+	if rejected {
+		agreed = errAgreed
+	}
+
+	// without quorum nobody is trusted
+	var (
+		divergent []QuorumProvider
+		errs      []error
+	)
+	for i, answer := range answers {
+		if !(reached || rejected) || !slices.Contains(agreed, i) {
+			divergent = append(divergent, answer.provider)
+			errs = append(errs, answer.err)
+		}
+	}
+
+	if len(divergent) > 0 {
+		q.reportDivergence(divergent, errs, index)
+	}
+
+	switch {
+	case reached:
+		return answers[agreed[0]].values[index], nil
+	case rejected:
+		return zero, answers[agreed[0]].err
+	// block may be still propagating to providers which do not know it yet
+	case len(agreed)+notFound >= q.config.Threshold:
+		return zero, fmt.Errorf("%w: %w", ErrQuorumNotReached, ErrBlockNotFound)
+	}
+
+	return zero, ErrQuorumNotReached
+}
+
+// quorumErrorKey groups JSON-RPC errors which providers return for the same call regardless of their
+// state. Failures of transport or of the node itself are never agreed on, empty key means such error.
+func quorumErrorKey(err error) string {
+	// nodes word unsupported method differently, some of them with server error code
+	if IsMethodNotFound(err) {
+		return "method_not_found"
+	}
+
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code == 0 || IsServerError(err) || IsRateLimited(err) {
+		return ""
+	}
+
+	return fmt.Sprintf("%d:%s", rpcErr.Code, rpcErr.Message)
+}
+
+// blockFingerprint identifies block by its hash and hashes of its transactions.
+func blockFingerprint(block *Block) string {
+	if block == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(block.Hash)
+	for _, transaction := range block.Transactions {
+		b.WriteByte(':')
+		b.WriteString(transaction.Hash)
+	}
+
+	return b.String()
+}
+
+// receiptFingerprint identifies receipt by transaction and its execution result.
+func receiptFingerprint(receipt *Receipt) string {
+	if receipt == nil {
+		return ""
+	}
+
+	return strings.Join([]string{
+		receipt.TransactionHash,
+		receipt.BlockHash,
+		receipt.Status,
+		receipt.GasUsed,
+		receipt.EffectiveGasPrice,
+		receipt.ContractAddress,
+	}, ":")
+}
+
+// logFingerprint identifies log by its position in chain and content.
+func logFingerprint(log *Log) string {
+	return strings.Join([]string{
+		log.BlockHash,
+		log.TransactionHash,
+		log.LogIndex,
+		log.Address,
+		strings.Join(log.Topics, ","),
+		log.Data,
+		strconv.FormatBool(log.Removed),
+	}, ":")
+}
+
+// writeCallFrameFingerprint identifies call tree by parties, value and result of every call.
+func writeCallFrameFingerprint(b *strings.Builder, frame *CallFrame) {
+	b.WriteString(strings.Join([]string{"(", frame.Type, frame.From, frame.To, frame.Value, frame.Error}, ":"))
+	for i := range frame.Calls {
+		writeCallFrameFingerprint(b, &frame.Calls[i])
+	}
+	b.WriteByte(')')
+}
+
+// traceFingerprint identifies flat trace by its position in call tree, action and result.
+func traceFingerprint(trace *Trace) string {
+	address := make([]string, len(trace.TraceAddress))
+	for i, position := range trace.TraceAddress {
+		address[i] = strconv.Itoa(position)
+	}
+
+	var created string
+	if trace.Result != nil {
+		created = trace.Result.Address
+	}
+
+	return strings.Join([]string{
+		trace.BlockHash,
+		trace.TransactionHash,
+		strings.Join(address, ","),
+		trace.Type,
+		trace.Action.CallType,
+		trace.Action.From,
+		trace.Action.To,
+		trace.Action.Value,
+		trace.Action.Address,
+		trace.Action.RefundAddress,
+		trace.Action.Balance,
+		created,
+		trace.Error,
+	}, ":")
+}
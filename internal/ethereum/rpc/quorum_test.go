@@ -0,0 +1,390 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/rpc"
+)
+
+// stubBlockReader answers every number with a copy of the same block and the same traces,
+// every hash with the same receipt and every filter with the same logs.
+type stubBlockReader struct {
+	block   *rpc.Block
+	receipt *rpc.Receipt
+	logs    []*rpc.Log
+	traces  []*rpc.TransactionTrace
+	err     error
+}
+
+func (s *stubBlockReader) GetBlockByNumber(ctx context.Context, number string) (*rpc.Block, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	block := *s.block
+	if number != rpc.NumberLatest {
+		block.Number = number
+	}
+
+	return &block, nil
+}
+
+func (s *stubBlockReader) GetBlocksByNumber(ctx context.Context, numbers []string) ([]*rpc.Block, error) {
+	blocks := make([]*rpc.Block, 0, len(numbers))
+	for _, number := range numbers {
+		block, err := s.GetBlockByNumber(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+func (s *stubBlockReader) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*rpc.Receipt, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	receipts := make([]*rpc.Receipt, 0, len(hashes))
+	for _, hash := range hashes {
+		receipt := *s.receipt
+		receipt.TransactionHash = hash
+		receipts = append(receipts, &receipt)
+	}
+
+	return receipts, nil
+}
+
+func (s *stubBlockReader) GetBlockReceipts(ctx context.Context, number string) ([]*rpc.Receipt, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.receipt == nil {
+		return []*rpc.Receipt{}, nil
+	}
+
+	return []*rpc.Receipt{s.receipt}, nil
+}
+
+func (s *stubBlockReader) GetLogs(ctx context.Context, filter rpc.LogFilter) ([]*rpc.Log, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.logs == nil {
+		return []*rpc.Log{}, nil
+	}
+
+	return s.logs, nil
+}
+
+func (s *stubBlockReader) TraceBlockByNumber(ctx context.Context, number string) ([]*rpc.TransactionTrace, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.traces == nil {
+		return []*rpc.TransactionTrace{}, nil
+	}
+
+	return s.traces, nil
+}
+
+func (s *stubBlockReader) TraceBlock(ctx context.Context, number string) ([]*rpc.Trace, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return []*rpc.Trace{}, nil
+}
+
+func newTestQuorum(t *testing.T, threshold int, readers ...*stubBlockReader) *rpc.Quorum {
+	providers := make([]rpc.QuorumProvider, 0, len(readers))
+	for i, reader := range readers {
+		providers = append(providers, rpc.QuorumProvider{
+			URL:    string(rune('a' + i)),
+			Client: reader,
+		})
+	}
+
+	quorum, err := rpc.NewQuorum(providers, rpc.QuorumConfig{Threshold: threshold})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return quorum
+}
+
+func TestQuorumInvalidThreshold(t *testing.T) {
+	testCases := map[string]struct {
+		threshold int
+		readers   []*stubBlockReader
+		err       error
+	}{
+		"above providers": {
+			threshold: 3,
+			readers:   []*stubBlockReader{{}},
+			err:       rpc.ErrInvalidQuorumThreshold,
+		},
+		"negative": {
+			threshold: -1,
+			readers:   []*stubBlockReader{{}, {}},
+			err:       rpc.ErrInvalidQuorumThreshold,
+		},
+		"no providers": {
+			err: rpc.ErrNoEndpoints,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			providers := make([]rpc.QuorumProvider, 0, len(tc.readers))
+			for _, reader := range tc.readers {
+				providers = append(providers, rpc.QuorumProvider{URL: "a", Client: reader})
+			}
+
+			// act
+			quorum, err := rpc.NewQuorum(providers, rpc.QuorumConfig{Threshold: tc.threshold})
+
+			// assert
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, quorum)
+		})
+	}
+}
+
+func TestQuorumAgreed(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	canonical := &rpc.Block{Hash: "h1", Transactions: []rpc.Transaction{{Hash: "t1"}}}
+	forged := &rpc.Block{Hash: "h1", Transactions: []rpc.Transaction{{Hash: "t2"}}}
+
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{block: canonical},
+		&stubBlockReader{block: forged},
+		&stubBlockReader{block: canonical},
+	)
+
+	// act
+	block, err := quorum.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0x1", block.Number)
+		assert.Equal(t, "t1", block.Transactions[0].Hash)
+	}
+	assert.Equal(t, map[string]int{"b": 1}, quorum.Divergences())
+}
+
+func TestQuorumNotReached(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{block: &rpc.Block{Hash: "h1"}},
+		&stubBlockReader{block: &rpc.Block{Hash: "h2"}},
+		&stubBlockReader{err: errors.New("any error")},
+	)
+
+	// act
+	block, err := quorum.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrQuorumNotReached)
+	assert.False(t, rpc.IsBlockNotFound(err))
+	assert.Nil(t, block)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, quorum.Divergences())
+}
+
+func TestQuorumBlockPropagating(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{block: &rpc.Block{Hash: "h1"}},
+		&stubBlockReader{err: rpc.ErrBlockNotFound},
+	)
+
+	// act
+	_, err := quorum.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrQuorumNotReached)
+	assert.True(t, rpc.IsBlockNotFound(err))
+}
+
+func TestQuorumMethodNotFound(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{err: &rpc.Error{Method: "eth_getBlockReceipts", Code: rpc.CodeMethodNotFound, Message: "Method not found"}},
+		&stubBlockReader{err: &rpc.Error{Method: "eth_getBlockReceipts", Code: -32000, Message: "the method eth_getBlockReceipts does not exist/is not available"}},
+		&stubBlockReader{receipt: &rpc.Receipt{TransactionHash: "t1"}},
+	)
+
+	// act
+	receipts, err := quorum.GetBlockReceipts(context.Background(), "0x1")
+
+	// assert
+	assert.True(t, rpc.IsMethodNotFound(err))
+	assert.NotErrorIs(t, err, rpc.ErrQuorumNotReached)
+	assert.Nil(t, receipts)
+	assert.Equal(t, map[string]int{"c": 1}, quorum.Divergences())
+}
+
+func TestQuorumErrorsNotAgreed(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{err: &rpc.Error{Method: "trace_block", Code: rpc.CodeMethodNotFound, Message: "Method not found"}},
+		&stubBlockReader{err: &rpc.Error{Method: "trace_block", HTTPStatus: 502, Message: "Bad Gateway"}},
+		&stubBlockReader{err: &rpc.Error{Method: "trace_block", HTTPStatus: 502, Message: "Bad Gateway"}},
+	)
+
+	// act
+	_, err := quorum.TraceBlock(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrQuorumNotReached)
+	assert.False(t, rpc.IsMethodNotFound(err))
+}
+
+func TestQuorumLatest(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{block: &rpc.Block{Number: "0x12", Hash: "h"}},
+		&stubBlockReader{block: &rpc.Block{Number: "0x10", Hash: "h"}},
+		&stubBlockReader{block: &rpc.Block{Number: "0x11", Hash: "h"}},
+	)
+
+	// act
+	block, err := quorum.GetBlockByNumber(context.Background(), rpc.NumberLatest)
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0x11", block.Number)
+	}
+}
+
+func TestQuorumGetBlocksByNumber(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 0,
+		&stubBlockReader{block: &rpc.Block{Hash: "h"}},
+		&stubBlockReader{block: &rpc.Block{Hash: "h"}},
+		&stubBlockReader{err: errors.New("any error")},
+	)
+
+	// act
+	blocks, err := quorum.GetBlocksByNumber(context.Background(), []string{"0x1", "0x2"})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, blocks, 2) {
+		assert.Equal(t, "0x1", blocks[0].Number)
+		assert.Equal(t, "0x2", blocks[1].Number)
+	}
+}
+
+func TestQuorumGetTransactionReceipts(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	success := &rpc.Receipt{Status: rpc.ReceiptStatusSuccess, GasUsed: "0x5208"}
+	failed := &rpc.Receipt{Status: rpc.ReceiptStatusFailed, GasUsed: "0x5208"}
+
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{receipt: failed},
+		&stubBlockReader{receipt: success},
+		&stubBlockReader{receipt: success},
+	)
+
+	// act
+	receipts, err := quorum.GetTransactionReceipts(context.Background(), []string{"0xa"})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, receipts, 1) {
+		assert.Equal(t, "0xa", receipts[0].TransactionHash)
+		assert.Equal(t, rpc.ReceiptStatusSuccess, receipts[0].Status)
+	}
+	assert.Equal(t, map[string]int{"a": 1}, quorum.Divergences())
+}
+
+func TestQuorumGetBlockReceiptsEmptyBlock(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{},
+		&stubBlockReader{},
+	)
+
+	// act
+	receipts, err := quorum.GetBlockReceipts(context.Background(), "0x1")
+
+	// assert
+	assert.NoError(t, err)
+	assert.Empty(t, receipts)
+}
+
+func TestQuorumGetLogs(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	canonical := []*rpc.Log{{BlockHash: "h1", LogIndex: "0x0", Data: "0x1"}}
+	forged := []*rpc.Log{{BlockHash: "h1", LogIndex: "0x0", Data: "0x2"}}
+
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{logs: canonical},
+		&stubBlockReader{logs: forged},
+		&stubBlockReader{logs: canonical},
+	)
+
+	// act
+	logs, err := quorum.GetLogs(context.Background(), rpc.LogFilter{BlockHash: "h1"})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, logs, 1) {
+		assert.Equal(t, "0x1", logs[0].Data)
+	}
+	assert.Equal(t, map[string]int{"b": 1}, quorum.Divergences())
+}
+
+func TestQuorumTraceBlockByNumber(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	trace := func(value string) []*rpc.TransactionTrace {
+		return []*rpc.TransactionTrace{{
+			TxHash: "0xa",
+			Result: &rpc.CallFrame{Type: "CALL", Calls: []rpc.CallFrame{{Type: "CALL", To: "0xb", Value: value}}},
+		}}
+	}
+
+	quorum := newTestQuorum(t, 2,
+		&stubBlockReader{traces: trace("0x1")},
+		&stubBlockReader{traces: trace("0x1")},
+		&stubBlockReader{traces: trace("0x2")},
+	)
+
+	// act
+	traces, err := quorum.TraceBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, traces, 1) {
+		assert.Equal(t, "0x1", traces[0].Result.Calls[0].Value)
+	}
+	assert.Equal(t, map[string]int{"c": 1}, quorum.Divergences())
+}
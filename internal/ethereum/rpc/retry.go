@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultRetryMaxAttempts = 5
+	DefaultRetryMinBackoff  = 500 * time.Millisecond
+	DefaultRetryMaxBackoff  = 30 * time.Second
+	DefaultRetryJitter      = 0.2
+)
+
+type (
+	RetryConfig struct {
+		// MaxAttempts is the number of tries of a call including the first one.
+		MaxAttempts int
+		// MinBackoff is the delay before the first retry, it doubles with every next one.
+		MinBackoff time.Duration
+		// MaxBackoff caps the delay between retries unless node asks for more with Retry-After.
+		MaxBackoff time.Duration
+		// Jitter is the fraction of backoff which is randomized, so clients don't retry at the same time.
+		Jitter float64
+	}
+
+	// Retry repeats failed idempotent calls of the wrapped Caller with exponential backoff.
+	// Typed methods come from embedded Client.
+	Retry struct {
+		*Client
+
+		caller Caller
+		config RetryConfig
+	}
+)
+
+// nonIdempotentMethods change node state, so they are never sent twice.
+var nonIdempotentMethods = map[string]bool{
+	"eth_sendTransaction":    true,
+	"eth_sendRawTransaction": true,
+	methodSubscribe:          true,
+	methodUnsubscribe:        true,
+}
+
+func NewRetry(caller Caller, config RetryConfig) *Retry {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultRetryMinBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if config.Jitter <= 0 || config.Jitter > 1 {
+		config.Jitter = DefaultRetryJitter
+	}
+
+	r := &Retry{
+		caller: caller,
+		config: config,
+	}
+	r.Client = NewClient(r)
+
+	return r
+}
+
+func (r *Retry) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if nonIdempotentMethods[method] {
+		return r.caller.Call(ctx, result, method, params...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.caller.Call(ctx, result, method, params...)
+		if err == nil || attempt == r.config.MaxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		if err := r.wait(ctx, method, attempt, err); err != nil {
+			return err
+		}
+	}
+}
+
+// BatchCall repeats the whole batch when it failed and then only the calls which failed with retryable error.
+func (r *Retry) BatchCall(ctx context.Context, elems []BatchElem) error {
+	for _, elem := range elems {
+		if nonIdempotentMethods[elem.Method] {
+			return r.caller.BatchCall(ctx, elems)
+		}
+	}
+
+	pending := make([]int, len(elems))
+	for i := range elems {
+		pending[i] = i
+	}
+
+	for attempt := 1; ; attempt++ {
+		batch := make([]BatchElem, len(pending))
+		for i, index := range pending {
+			batch[i] = elems[index]
+			batch[i].Error = nil
+		}
+
+		err := r.caller.BatchCall(ctx, batch)
+		if err != nil {
+			if attempt == r.config.MaxAttempts || !IsRetryable(err) {
+				return err
+			}
+		} else {
+			var failed []int
+			for i, index := range pending {
+				elems[index].Error = batch[i].Error
+				if batch[i].Error != nil && IsRetryable(batch[i].Error) {
+					failed = append(failed, index)
+				}
+			}
+
+			if len(failed) == 0 || attempt == r.config.MaxAttempts {
+				return nil
+			}
+
+			pending = failed
+			err = elems[failed[0]].Error
+		}
+
+		if err := r.wait(ctx, methodBatch, attempt, err); err != nil {
+			return err
+		}
+	}
+}
+
+// wait sleeps before the next attempt, it returns ctx error when ctx is done earlier.
+func (r *Retry) wait(ctx context.Context, method string, attempt int, cause error) error {
+	delay := r.backoff(attempt)
+
+	var rpcErr *Error
+	if errors.As(cause, &rpcErr) && rpcErr.RetryAfter > delay {
+		delay = rpcErr.RetryAfter
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"method":  method,
+			"attempt": attempt,
+			"delay":   delay,
+		}).
+		WithError(cause).
+		Warn("RPC call failed, retrying")
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *Retry) backoff(attempt int) time.Duration {
+	delay := r.config.MaxBackoff
+	if shift := attempt - 1; shift < 32 && r.config.MinBackoff<<shift < r.config.MaxBackoff {
+		delay = r.config.MinBackoff << shift
+	}
+
+	jitter := time.Duration(float64(delay) * r.config.Jitter * rand.Float64())
+
+	return delay - jitter
+}
+
+// IsRetryable reports whether the same call may succeed later: node is unreachable,
+// overloaded or failed internally. Rejected calls and canceled contexts are not retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		if rpcErr.HTTPStatus != 0 && rpcErr.HTTPStatus != http.StatusOK {
+			return rpcErr.HTTPStatus == http.StatusRequestTimeout ||
+				rpcErr.HTTPStatus == http.StatusTooManyRequests ||
+				rpcErr.HTTPStatus >= http.StatusInternalServerError
+		}
+
+		return IsRateLimited(err) || IsServerError(err)
+	}
+
+	return errors.Is(err, ErrEthereumServerUnavailable) || errors.Is(err, ErrConnectionClosed)
+}
@@ -0,0 +1,222 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/rpc"
+)
+
+var testRetryConfig = rpc.RetryConfig{
+	MaxAttempts: 3,
+	MinBackoff:  time.Millisecond,
+	MaxBackoff:  5 * time.Millisecond,
+}
+
+// newFlakyServer fails the first failures requests with status and answers a block afterwards.
+func newFlakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"number": "0x1"}}`))
+	}))
+
+	return server, &requests
+}
+
+func TestRetryCallRecovers(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, requests := newFlakyServer(2, http.StatusBadGateway)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), testRetryConfig)
+
+	// act
+	block, err := client.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0x1", block.Number)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
+
+func TestRetryCallGivesUp(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, requests := newFlakyServer(10, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), testRetryConfig)
+
+	// act
+	block, err := client.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrEthereumServerUnavailable)
+	assert.Nil(t, block)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
+
+func TestRetryCallNotRetryable(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, requests := newFlakyServer(10, http.StatusBadRequest)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), testRetryConfig)
+
+	// act
+	_, err := client.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestRetryCallNonIdempotent(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, requests := newFlakyServer(10, http.StatusBadGateway)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), testRetryConfig)
+
+	// act
+	err := client.Call(context.Background(), nil, "eth_sendRawTransaction", "0x00")
+
+	// assert
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestRetryCallRetryAfter(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"number": "0x1"}}`))
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), testRetryConfig)
+	start := time.Now()
+
+	// act
+	_, err := client.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestRetryCallContextCanceled(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, requests := newFlakyServer(10, http.StatusBadGateway)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), rpc.RetryConfig{
+		MaxAttempts: 3,
+		MinBackoff:  time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// act
+	_, err := client.GetBlockByNumber(ctx, "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestRetryBatchCallRetriesFailedItems(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := atomic.AddInt32(&attempts, 1)
+
+		var requests []map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&requests)
+
+		responses := make([]map[string]interface{}, 0, len(requests))
+		for _, request := range requests {
+			number := request["params"].([]interface{})[0].(string)
+			response := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      request["id"],
+			}
+			if number == "0x2" && attempt == 1 {
+				response["error"] = map[string]interface{}{"code": rpc.CodeLimitExceeded, "message": "limit exceeded"}
+			} else {
+				response["result"] = map[string]interface{}{"number": number}
+			}
+			responses = append(responses, response)
+		}
+
+		if attempt == 2 {
+			assert.Len(t, requests, 1)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewRetry(rpc.NewHttp(&http.Client{}, server.URL), testRetryConfig)
+
+	// act
+	blocks, err := client.GetBlocksByNumber(context.Background(), []string{"0x1", "0x2"})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, blocks, 2) {
+		assert.Equal(t, "0x1", blocks[0].Number)
+		assert.Equal(t, "0x2", blocks[1].Number)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestIsRetryable(t *testing.T) {
+	// assert
+	assert.True(t, rpc.IsRetryable(rpc.ErrEthereumServerUnavailable))
+	assert.True(t, rpc.IsRetryable(rpc.ErrConnectionClosed))
+	assert.True(t, rpc.IsRetryable(&rpc.Error{HTTPStatus: http.StatusTooManyRequests}))
+	assert.True(t, rpc.IsRetryable(&rpc.Error{Code: rpc.CodeInternalError}))
+	assert.False(t, rpc.IsRetryable(&rpc.Error{HTTPStatus: http.StatusUnauthorized}))
+	assert.False(t, rpc.IsRetryable(&rpc.Error{Code: rpc.CodeInvalidParams}))
+	assert.False(t, rpc.IsRetryable(rpc.ErrBlockNotFound))
+	assert.False(t, rpc.IsRetryable(context.Canceled))
+}
@@ -0,0 +1,574 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SubscriptionNewHeads = "newHeads"
+
+	methodSubscribe    = "eth_subscribe"
+	methodUnsubscribe  = "eth_unsubscribe"
+	methodSubscription = "eth_subscription"
+
+	subscriptionBufferSize = 16
+	reconnectMinDelay      = time.Second
+	reconnectMaxDelay      = 30 * time.Second
+	unsubscribeTimeout     = 5 * time.Second
+
+	DefaultWebSocketPingInterval = 30 * time.Second
+	DefaultWebSocketPongTimeout  = 10 * time.Second
+)
+
+type (
+	Header struct {
+		Number     string `json:"number"`
+		Hash       string `json:"hash"`
+		ParentHash string `json:"parentHash"`
+	}
+
+	wsNotification struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	}
+
+	// wsMessage is either a call response or a subscription notification.
+	wsMessage struct {
+		rpcResponse
+		Method string          `json:"method"`
+		Params *wsNotification `json:"params"`
+	}
+
+	wsPendingCall struct {
+		response chan rpcResponse
+		// notifications is registered as subscription when response of eth_subscribe arrives
+		notifications chan json.RawMessage
+	}
+
+	WebSocketConfig struct {
+		// PingInterval is the period of pings sent to node to detect half-open connection.
+		PingInterval time.Duration
+		// PongTimeout is the time node has to answer ping, connection without any message
+		// for PingInterval and PongTimeout is considered lost.
+		PongTimeout time.Duration
+	}
+
+	// Subscription receives notifications until connection is lost or it is unsubscribed,
+	// then Notifications is closed.
+	Subscription struct {
+		id            string
+		ws            *WebSocket
+		notifications chan json.RawMessage
+	}
+
+	// WebSocket is JSON-RPC client over WebSocket connection which is dialed on demand.
+	// Typed methods come from embedded Client, push notifications from Subscribe.
+	WebSocket struct {
+		*Client
+
+		url    string
+		dialer *websocket.Dialer
+		config WebSocketConfig
+
+		idCounter uint64
+
+		mu            sync.Mutex
+		conn          *websocket.Conn
+		pending       map[uint64]*wsPendingCall
+		subscriptions map[string]chan json.RawMessage
+
+		writeMu sync.Mutex
+	}
+)
+
+var ErrConnectionClosed = errors.New("websocket connection is closed")
+
+func NewWebSocket(dialer *websocket.Dialer, url string) *WebSocket {
+	return NewWebSocketWithConfig(dialer, url, WebSocketConfig{})
+}
+
+func NewWebSocketWithConfig(dialer *websocket.Dialer, url string, config WebSocketConfig) *WebSocket {
+	if config.PingInterval <= 0 {
+		config.PingInterval = DefaultWebSocketPingInterval
+	}
+	if config.PongTimeout <= 0 {
+		config.PongTimeout = DefaultWebSocketPongTimeout
+	}
+
+	w := &WebSocket{
+		url:           url,
+		dialer:        dialer,
+		config:        config,
+		pending:       make(map[uint64]*wsPendingCall),
+		subscriptions: make(map[string]chan json.RawMessage),
+	}
+	w.Client = NewClient(w)
+
+	return w
+}
+
+func (w *WebSocket) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	resp, err := w.call(ctx, method, params, nil)
+	if err != nil {
+		return fmt.Errorf("error during %s request: %w", method, err)
+	}
+
+	return unmarshalResult(method, resp.Result, result)
+}
+
+// BatchCall sends all elements in one message, errors of single calls are set to BatchElem.Error.
+func (w *WebSocket) BatchCall(ctx context.Context, elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
+	}
+
+	conn, err := w.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("error during batch request: %w", err)
+	}
+
+	requests := make([]rpcRequest, len(elems))
+	ids := make([]uint64, len(elems))
+	calls := make([]*wsPendingCall, len(elems))
+	for i, elem := range elems {
+		ids[i] = atomic.AddUint64(&w.idCounter, 1)
+		requests[i] = newRPCRequest(ids[i], elem.Method, elem.Params)
+	}
+	defer w.unregister(ids...)
+
+	for i, id := range ids {
+		calls[i], err = w.register(conn, id, nil)
+		if err != nil {
+			return fmt.Errorf("error during batch request: %w", err)
+		}
+	}
+
+	if err := w.write(conn, requests); err != nil {
+		return fmt.Errorf("error during batch request: %w", err)
+	}
+
+	responses := make([]rpcResponse, 0, len(elems))
+	for _, call := range calls {
+		select {
+		case resp, ok := <-call.response:
+			if !ok {
+				return fmt.Errorf("error during batch request: %w", ErrConnectionClosed)
+			}
+			responses = append(responses, resp)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	dispatchBatch(elems, ids, responses)
+
+	return nil
+}
+
+// Subscribe starts eth_subscribe subscription of the given kind, e.g. SubscriptionNewHeads.
+func (w *WebSocket) Subscribe(ctx context.Context, kind string) (*Subscription, error) {
+	notifications := make(chan json.RawMessage, subscriptionBufferSize)
+
+	resp, err := w.call(ctx, methodSubscribe, []interface{}{kind}, notifications)
+	if err != nil {
+		return nil, fmt.Errorf("error during %s request: %w", methodSubscribe, err)
+	}
+
+	var id string
+	if err := unmarshalResult(methodSubscribe, resp.Result, &id); err != nil {
+		return nil, err
+	}
+
+	return &Subscription{
+		id:            id,
+		ws:            w,
+		notifications: notifications,
+	}, nil
+}
+
+// WatchNewHeads sends new chain heads to heads until ctx is done, then it unsubscribes. Heads which
+// arrive while the previous one is not taken are coalesced to the latest, processing catches up
+// every block up to it anyway. Lost connection is dialed again with exponential delay and
+// subscription is renewed.
+func (w *WebSocket) WatchNewHeads(ctx context.Context, heads chan<- Header) {
+	delay := reconnectMinDelay
+
+	for {
+		subscription, err := w.Subscribe(ctx, SubscriptionNewHeads)
+		if err != nil {
+			logrus.
+				WithFields(logrus.Fields{
+					"url":   w.url,
+					"delay": delay,
+				}).
+				WithError(err).
+				Warn("failed to subscribe to new heads")
+		} else {
+			delay = reconnectMinDelay
+			subscription.forwardHeads(ctx, heads)
+
+			if ctx.Err() != nil {
+				subscription.unsubscribeOnShutdown()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, reconnectMaxDelay)
+	}
+}
+
+// Close closes current connection, the next call dials a new one.
+func (w *WebSocket) Close() error {
+	w.mu.Lock()
+	conn := w.conn
+	w.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	return conn.Close()
+}
+
+func (w *WebSocket) call(ctx context.Context, method string, params []interface{}, notifications chan json.RawMessage) (*rpcResponse, error) {
+	conn, err := w.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := atomic.AddUint64(&w.idCounter, 1)
+	call, err := w.register(conn, id, notifications)
+	if err != nil {
+		return nil, err
+	}
+	defer w.unregister(id)
+
+	request := newRPCRequest(id, method, params)
+	if err := w.write(conn, &request); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-call.response:
+		if !ok {
+			return nil, ErrConnectionClosed
+		}
+		if resp.Error != nil {
+			return nil, newResponseError(method, resp.Error)
+		}
+
+		return &resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (w *WebSocket) connect(ctx context.Context) (*websocket.Conn, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		return w.conn, nil
+	}
+
+	conn, _, err := w.dialer.DialContext(ctx, w.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEthereumServerUnavailable, err)
+	}
+
+	w.conn = conn
+	go w.readLoop(conn)
+
+	logrus.
+		WithFields(logrus.Fields{
+			"url": w.url,
+		}).
+		Info("WebSocket connection was established")
+
+	return conn, nil
+}
+
+func (w *WebSocket) write(conn *websocket.Conn, payload interface{}) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	if err := conn.WriteJSON(payload); err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+
+	return nil
+}
+
+// register waits for response of id on conn, it fails if conn was dropped meanwhile.
+func (w *WebSocket) register(conn *websocket.Conn, id uint64, notifications chan json.RawMessage) (*wsPendingCall, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != conn {
+		return nil, ErrConnectionClosed
+	}
+
+	call := &wsPendingCall{
+		response:      make(chan rpcResponse, 1),
+		notifications: notifications,
+	}
+	w.pending[id] = call
+
+	return call, nil
+}
+
+func (w *WebSocket) unregister(ids ...uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, id := range ids {
+		delete(w.pending, id)
+	}
+}
+
+// readLoop dispatches messages of conn until it fails. Connection which sent neither message
+// nor pong for PingInterval and PongTimeout fails too, so half-open connection is dropped.
+func (w *WebSocket) readLoop(conn *websocket.Conn) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go w.keepAlive(conn, stop)
+
+	timeout := w.config.PingInterval + w.config.PongTimeout
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			w.drop(conn, err)
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(timeout))
+
+		if trimmed := bytes.TrimSpace(message); len(trimmed) > 0 && trimmed[0] == '[' {
+			var responses []rpcResponse
+			if err := json.Unmarshal(trimmed, &responses); err != nil {
+				logrus.WithError(err).Warn("failed to decode websocket batch response")
+				continue
+			}
+
+			for _, resp := range responses {
+				w.deliver(resp)
+			}
+			continue
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			logrus.WithError(err).Warn("failed to decode websocket message")
+			continue
+		}
+
+		if msg.Method == methodSubscription && msg.Params != nil {
+			w.notify(msg.Params)
+			continue
+		}
+
+		w.deliver(msg.rpcResponse)
+	}
+}
+
+// keepAlive pings node every PingInterval until stop is closed. Connection is closed when ping
+// can not be sent, so readLoop fails instead of waiting for deadline.
+func (w *WebSocket) keepAlive(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(w.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(w.config.PongTimeout)); err != nil {
+				logrus.
+					WithFields(logrus.Fields{
+						"url": w.url,
+					}).
+					WithError(err).
+					Warn("failed to ping websocket node")
+
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (w *WebSocket) deliver(resp rpcResponse) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	call, ok := w.pending[resp.ID]
+	if !ok {
+		return
+	}
+	delete(w.pending, resp.ID)
+
+	// subscription is registered before the caller gets its id, so no notification is lost
+	if call.notifications != nil && resp.Error == nil {
+		var id string
+		if err := json.Unmarshal(resp.Result, &id); err == nil {
+			w.subscriptions[id] = call.notifications
+		}
+	}
+
+	call.response <- resp
+}
+
+func (w *WebSocket) notify(notification *wsNotification) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	notifications, ok := w.subscriptions[notification.Subscription]
+	if !ok {
+		return
+	}
+
+	select {
+	case notifications <- notification.Result:
+	default:
+		logrus.
+			WithFields(logrus.Fields{
+				"subscription": notification.Subscription,
+			}).
+			Warn("Subscription buffer is full, notification was dropped")
+	}
+}
+
+// drop forgets the broken connection and fails every pending call and subscription.
+func (w *WebSocket) drop(conn *websocket.Conn, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == conn {
+		w.conn = nil
+	}
+	conn.Close()
+
+	for id, call := range w.pending {
+		close(call.response)
+		delete(w.pending, id)
+	}
+
+	for id, notifications := range w.subscriptions {
+		close(notifications)
+		delete(w.subscriptions, id)
+	}
+
+	logrus.
+		WithFields(logrus.Fields{
+			"url": w.url,
+		}).
+		WithError(err).
+		Warn("WebSocket connection was closed")
+}
+
+// Notifications is closed when connection is lost or subscription is unsubscribed.
+func (s *Subscription) Notifications() <-chan json.RawMessage {
+	return s.notifications
+}
+
+// Unsubscribe closes Notifications, it is closed already when connection was lost.
+func (s *Subscription) Unsubscribe(ctx context.Context) error {
+	s.ws.mu.Lock()
+	// notifications are sent under the same lock, so none is sent to closed channel
+	if notifications, ok := s.ws.subscriptions[s.id]; ok {
+		delete(s.ws.subscriptions, s.id)
+		close(notifications)
+	}
+	s.ws.mu.Unlock()
+
+	return s.ws.Call(ctx, nil, methodUnsubscribe, s.id)
+}
+
+// forwardHeads keeps only the latest head which is not taken yet, so slow consumer does not
+// fill notifications buffer and newer heads are not dropped.
+func (s *Subscription) forwardHeads(ctx context.Context, heads chan<- Header) {
+	var (
+		latest  Header
+		pending bool
+	)
+
+	for {
+		// nil channel disables sending while there is no head
+		var out chan<- Header
+		if pending {
+			out = heads
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case out <- latest:
+			pending = false
+		case raw, ok := <-s.notifications:
+			if !ok {
+				// connection is lost, head received before it is still handed over
+				if pending {
+					select {
+					case heads <- latest:
+					case <-ctx.Done():
+					}
+				}
+
+				return
+			}
+
+			var head Header
+			if err := json.Unmarshal(raw, &head); err != nil {
+				logrus.WithError(err).Warn("failed to decode new head")
+				continue
+			}
+
+			if pending {
+				logrus.
+					WithFields(logrus.Fields{
+						"number":       head.Number,
+						"replaced":     latest.Number,
+						"subscription": s.id,
+					}).
+					Debug("New head replaced the one which was not taken yet")
+			}
+			latest = head
+			pending = true
+		}
+	}
+}
+
+// unsubscribeOnShutdown cancels subscription on node when watching is stopped, so node does not
+// keep sending heads to connection which may be still used for calls.
+func (s *Subscription) unsubscribeOnShutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+
+	if err := s.Unsubscribe(ctx); err != nil {
+		logrus.
+			WithFields(logrus.Fields{
+				"subscription": s.id,
+			}).
+			WithError(err).
+			Warn("failed to unsubscribe from new heads")
+	}
+}
@@ -0,0 +1,397 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/rpc"
+)
+
+// newWebSocketServer starts a node stand-in which answers block requests and
+// sends one new head right after subscription, then closes connection if closeAfterHead is set.
+func newWebSocketServer(t *testing.T, closeAfterHead bool) (*httptest.Server, *int32) {
+	var connections int32
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		number := atomic.AddInt32(&connections, 1)
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if strings.HasPrefix(string(message), "[") {
+				var requests []map[string]interface{}
+				json.Unmarshal(message, &requests)
+
+				responses := make([]map[string]interface{}, 0, len(requests))
+				for _, request := range requests {
+					responses = append(responses, map[string]interface{}{
+						"jsonrpc": "2.0",
+						"id":      request["id"],
+						"result":  map[string]interface{}{"number": request["params"].([]interface{})[0]},
+					})
+				}
+				conn.WriteJSON(responses)
+				continue
+			}
+
+			var request map[string]interface{}
+			json.Unmarshal(message, &request)
+
+			switch request["method"] {
+			case "eth_getBlockByNumber":
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request["id"],
+					"result":  map[string]interface{}{"number": request["params"].([]interface{})[0]},
+				})
+			case "eth_subscribe":
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request["id"],
+					"result":  "0xsub",
+				})
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "eth_subscription",
+					"params": map[string]interface{}{
+						"subscription": "0xsub",
+						"result":       map[string]interface{}{"number": "0x" + string('0'+rune(number))},
+					},
+				})
+				if closeAfterHead {
+					return
+				}
+			case "eth_unsubscribe":
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request["id"],
+					"result":  true,
+				})
+			default:
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request["id"],
+					"error":   map[string]interface{}{"code": -32601, "message": "method not found"},
+				})
+			}
+		}
+	}))
+
+	return server, &connections
+}
+
+func webSocketURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestWebSocketGetBlockByNumber(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, _ := newWebSocketServer(t, false)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	// act
+	block, err := client.GetBlockByNumber(context.Background(), "0x10")
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0x10", block.Number)
+	}
+}
+
+func TestWebSocketGetBlocksByNumber(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, _ := newWebSocketServer(t, false)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	// act
+	blocks, err := client.GetBlocksByNumber(context.Background(), []string{"0x1", "0x2"})
+
+	// assert
+	if assert.NoError(t, err) && assert.Len(t, blocks, 2) {
+		assert.Equal(t, "0x1", blocks[0].Number)
+		assert.Equal(t, "0x2", blocks[1].Number)
+	}
+}
+
+func TestWebSocketRpcError(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, _ := newWebSocketServer(t, false)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	// act
+	err := client.Call(context.Background(), nil, "eth_unknown")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrRPCResponseError)
+}
+
+func TestWebSocketServerUnavailable(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, "ws://127.0.0.1:1")
+
+	// act
+	block, err := client.GetBlockByNumber(context.Background(), "0x1")
+
+	// assert
+	assert.ErrorIs(t, err, rpc.ErrEthereumServerUnavailable)
+	assert.Nil(t, block)
+}
+
+func TestWebSocketSubscribeNewHeads(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, _ := newWebSocketServer(t, false)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	// act
+	subscription, err := client.Subscribe(context.Background(), rpc.SubscriptionNewHeads)
+
+	// assert
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	select {
+	case raw := <-subscription.Notifications():
+		assert.JSONEq(t, `{"number":"0x1"}`, string(raw))
+	case <-time.After(time.Second):
+		assert.Fail(t, "notification is not received")
+	}
+}
+
+func TestWebSocketWatchNewHeadsReconnect(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, connections := newWebSocketServer(t, true)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	heads := make(chan rpc.Header)
+
+	// act
+	go client.WatchNewHeads(ctx, heads)
+
+	// assert
+	for _, expected := range []string{"0x1", "0x2"} {
+		select {
+		case head := <-heads:
+			assert.Equal(t, expected, head.Number)
+		case <-time.After(3 * time.Second):
+			assert.Fail(t, "head is not received")
+			return
+		}
+	}
+	assert.GreaterOrEqual(t, atomic.LoadInt32(connections), int32(2))
+}
+
+func TestWebSocketWatchNewHeadsCoalesceAndUnsubscribe(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// node sends three heads at once and reports unsubscription
+	unsubscribed := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var request map[string]interface{}
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+
+			switch request["method"] {
+			case "eth_subscribe":
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request["id"],
+					"result":  "0xsub",
+				})
+				for _, number := range []string{"0x1", "0x2", "0x3"} {
+					conn.WriteJSON(map[string]interface{}{
+						"jsonrpc": "2.0",
+						"method":  "eth_subscription",
+						"params": map[string]interface{}{
+							"subscription": "0xsub",
+							"result":       map[string]interface{}{"number": number},
+						},
+					})
+				}
+			case "eth_unsubscribe":
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request["id"],
+					"result":  true,
+				})
+				close(unsubscribed)
+			}
+		}
+	}))
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	heads := make(chan rpc.Header)
+	go client.WatchNewHeads(ctx, heads)
+
+	// act
+	// consumer is busy while all heads arrive
+	time.Sleep(100 * time.Millisecond)
+
+	// assert
+	select {
+	case head := <-heads:
+		assert.Equal(t, "0x3", head.Number)
+	case <-time.After(time.Second):
+		assert.Fail(t, "head is not received")
+		return
+	}
+
+	// act
+	cancel()
+
+	// assert
+	select {
+	case <-unsubscribed:
+	case <-time.After(time.Second):
+		assert.Fail(t, "subscription is not canceled on node")
+	}
+}
+
+func TestWebSocketUnsubscribe(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	server, _ := newWebSocketServer(t, false)
+	defer server.Close()
+
+	// arrange
+	client := rpc.NewWebSocket(websocket.DefaultDialer, webSocketURL(server))
+	defer client.Close()
+
+	subscription, err := client.Subscribe(context.Background(), rpc.SubscriptionNewHeads)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// act
+	err = subscription.Unsubscribe(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+	assertNotificationsClosed(t, subscription)
+}
+
+func TestWebSocketHalfOpenConnection(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	// node answers subscription, then stops reading, so pings are not answered
+	release := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		var request map[string]interface{}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request["id"],
+			"result":  "0xsub",
+		})
+
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// arrange
+	client := rpc.NewWebSocketWithConfig(websocket.DefaultDialer, webSocketURL(server), rpc.WebSocketConfig{
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  20 * time.Millisecond,
+	})
+	defer client.Close()
+
+	// act
+	subscription, err := client.Subscribe(context.Background(), rpc.SubscriptionNewHeads)
+
+	// assert
+	if assert.NoError(t, err) {
+		assertNotificationsClosed(t, subscription)
+	}
+}
+
+func assertNotificationsClosed(t *testing.T, subscription *rpc.Subscription) {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-subscription.Notifications():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			assert.Fail(t, "notifications are not closed")
+			return
+		}
+	}
+}
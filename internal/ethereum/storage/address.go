@@ -1,29 +1,235 @@
 package storage
 
 import (
+	"cmp"
 	"slices"
 	"sync"
+
+	"trust_walet/internal/ethereum/data"
 )
 
-type AddressInMemory struct {
-	data []string
-	mu   sync.RWMutex
-}
+const (
+	DefaultBloomCapacity          = 1 << 16
+	DefaultBloomFalsePositiveRate = 0.01
+)
+
+type (
+	AddressConfig struct {
+		// BloomFilter enables pre-check of lookups, which rejects most of not subscribed
+		// addresses before the map of subscriptions is looked up.
+		BloomFilter bool
+		// BloomCapacity is the expected number of addresses, the filter grows beyond it.
+		BloomCapacity int
+		// BloomFalsePositiveRate is the share of not subscribed addresses passing the pre-check.
+		BloomFalsePositiveRate float64
+	}
+
+	// AddressInMemory keeps subscriptions in a hash set, so lookup cost does not depend
+	// on the number of subscribed addresses.
+	AddressInMemory struct {
+		data map[string]data.Subscription
+		// seq numbers addresses in the order they were added, List is sorted by it
+		seq    map[string]uint64
+		next   uint64
+		bloom  *bloomFilter
+		config AddressConfig
+		mu     sync.RWMutex
+	}
+)
 
 func NewAddressInMemory() *AddressInMemory {
-	return &AddressInMemory{}
+	return NewAddressInMemoryWithConfig(AddressConfig{})
+}
+
+func NewAddressInMemoryWithConfig(config AddressConfig) *AddressInMemory {
+	if config.BloomCapacity <= 0 {
+		config.BloomCapacity = DefaultBloomCapacity
+	}
+	if config.BloomFalsePositiveRate <= 0 || config.BloomFalsePositiveRate >= 1 {
+		config.BloomFalsePositiveRate = DefaultBloomFalsePositiveRate
+	}
+
+	a := &AddressInMemory{
+		data:   make(map[string]data.Subscription),
+		seq:    make(map[string]uint64),
+		config: config,
+	}
+	if config.BloomFilter {
+		a.bloom = newBloomFilter(config.BloomCapacity, config.BloomFalsePositiveRate)
+	}
+
+	return a
 }
 
 func (a *AddressInMemory) Exists(address string) bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
-	return slices.Contains(a.data, address)
+	if a.bloom != nil && !a.bloom.mayContain(address) {
+		return false
+	}
+
+	_, ok := a.data[address]
+
+	return ok
 }
 
-func (a *AddressInMemory) Add(address string) {
+// Add returns false when address is already subscribed, its subscription is kept then.
+func (a *AddressInMemory) Add(subscription data.Subscription) (bool, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	a.data = append(a.data, address)
+	if _, ok := a.data[subscription.Address]; ok {
+		return false, nil
+	}
+
+	a.add(subscription)
+	a.rebuildBloom()
+
+	return true, nil
+}
+
+// AddMany adds subscriptions of addresses which are not added yet under a single lock,
+// it returns the added ones.
+func (a *AddressInMemory) AddMany(subscriptions []data.Subscription) ([]data.Subscription, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	added := a.missing(subscriptions)
+	for _, subscription := range added {
+		a.add(subscription)
+	}
+	a.rebuildBloom()
+
+	return added, nil
+}
+
+func (a *AddressInMemory) Get(address string) (data.Subscription, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	subscription, ok := a.data[address]
+
+	return subscription, ok
+}
+
+// Remove returns false when address is not subscribed.
+func (a *AddressInMemory) Remove(address string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	removed := a.remove(address)
+	a.rebuildBloom()
+
+	return removed, nil
+}
+
+// RemoveMany removes addresses under a single lock, it returns the ones which were subscribed.
+func (a *AddressInMemory) RemoveMany(addresses []string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	removed := a.subscribed(addresses)
+	for _, address := range removed {
+		a.remove(address)
+	}
+	a.rebuildBloom()
+
+	return removed, nil
+}
+
+// List returns subscriptions in the order their addresses were added.
+func (a *AddressInMemory) List() []data.Subscription {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	subscriptions := make([]data.Subscription, 0, len(a.data))
+	for _, subscription := range a.data {
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	slices.SortFunc(subscriptions, func(x, y data.Subscription) int {
+		return cmp.Compare(a.seq[x.Address], a.seq[y.Address])
+	})
+
+	return subscriptions
+}
+
+// missing returns subscriptions of addresses which are not added yet, the first one of repeated
+// addresses is kept. Caller must hold mu.
+func (a *AddressInMemory) missing(subscriptions []data.Subscription) []data.Subscription {
+	seen := make(map[string]struct{}, len(subscriptions))
+
+	var missing []data.Subscription
+	for _, subscription := range subscriptions {
+		if _, ok := a.data[subscription.Address]; ok {
+			continue
+		}
+		if _, ok := seen[subscription.Address]; ok {
+			continue
+		}
+		seen[subscription.Address] = struct{}{}
+
+		missing = append(missing, subscription)
+	}
+
+	return missing
+}
+
+// subscribed returns addresses which are added, each of them once. Caller must hold mu.
+func (a *AddressInMemory) subscribed(addresses []string) []string {
+	seen := make(map[string]struct{}, len(addresses))
+
+	var subscribed []string
+	for _, address := range addresses {
+		if _, ok := a.data[address]; !ok {
+			continue
+		}
+		if _, ok := seen[address]; ok {
+			continue
+		}
+		seen[address] = struct{}{}
+
+		subscribed = append(subscribed, address)
+	}
+
+	return subscribed
+}
+
+// add inserts subscription of address which is not added yet, caller must hold mu.
+func (a *AddressInMemory) add(subscription data.Subscription) {
+	a.next++
+	a.seq[subscription.Address] = a.next
+	if a.bloom != nil {
+		a.bloom.add(subscription.Address)
+	}
+
+	a.data[subscription.Address] = subscription
+}
+
+func (a *AddressInMemory) remove(address string) bool {
+	if _, ok := a.data[address]; !ok {
+		return false
+	}
+	delete(a.data, address)
+	delete(a.seq, address)
+
+	if a.bloom != nil {
+		a.bloom.remove()
+	}
+
+	return true
+}
+
+// rebuildBloom replaces stale bloom filter with a new one, sized for twice as many addresses
+// as subscribed now, so it is not rebuilt again on the next add.
+func (a *AddressInMemory) rebuildBloom() {
+	if a.bloom == nil || !a.bloom.stale() {
+		return
+	}
+
+	a.bloom = newBloomFilter(max(a.config.BloomCapacity, 2*len(a.data)), a.config.BloomFalsePositiveRate)
+	for address := range a.data {
+		a.bloom.add(address)
+	}
 }
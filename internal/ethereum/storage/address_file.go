@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"trust_walet/internal/ethereum/data"
+)
+
+const (
+	journalOpAddressAdd        = "add"
+	journalOpAddressRemove     = "remove"
+	journalOpAddressAddMany    = "add_many"
+	journalOpAddressRemoveMany = "remove_many"
+)
+
+// AddressFile is AddressInMemory which survives restarts by keeping changes in a journal file.
+// Journal is compacted on open, so it holds only subscribed addresses.
+type AddressFile struct {
+	*AddressInMemory
+
+	journal *journal
+}
+
+func NewAddressFile(path string) (*AddressFile, error) {
+	return NewAddressFileWithConfig(path, AddressConfig{})
+}
+
+func NewAddressFileWithConfig(path string, config AddressConfig) (*AddressFile, error) {
+	a := &AddressFile{
+		AddressInMemory: NewAddressInMemoryWithConfig(config),
+	}
+
+	journal, err := openJournal(path, a.replay)
+	if err != nil {
+		return nil, err
+	}
+	a.journal = journal
+
+	if err := a.compact(); err != nil {
+		journal.Close()
+
+		return nil, fmt.Errorf("error compacting address journal: %w", err)
+	}
+
+	return a, nil
+}
+
+// Add journals subscription before it is added, like every other change of AddressFile.
+func (a *AddressFile) Add(subscription data.Subscription) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.data[subscription.Address]; ok {
+		return false, nil
+	}
+
+	if err := a.journal.append(journalOpAddressAdd, subscription); err != nil {
+		return false, err
+	}
+	a.add(subscription)
+	a.rebuildBloom()
+
+	return true, nil
+}
+
+func (a *AddressFile) Remove(address string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.data[address]; !ok {
+		return false, nil
+	}
+
+	if err := a.journal.append(journalOpAddressRemove, address); err != nil {
+		return false, err
+	}
+	a.remove(address)
+	a.rebuildBloom()
+
+	return true, nil
+}
+
+// AddMany journals added subscriptions as a single record.
+func (a *AddressFile) AddMany(subscriptions []data.Subscription) ([]data.Subscription, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	added := a.missing(subscriptions)
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	if err := a.journal.append(journalOpAddressAddMany, added); err != nil {
+		return nil, err
+	}
+	for _, subscription := range added {
+		a.add(subscription)
+	}
+	a.rebuildBloom()
+
+	return added, nil
+}
+
+// RemoveMany journals removed addresses as a single record.
+func (a *AddressFile) RemoveMany(addresses []string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	removed := a.subscribed(addresses)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if err := a.journal.append(journalOpAddressRemoveMany, removed); err != nil {
+		return nil, err
+	}
+	for _, address := range removed {
+		a.remove(address)
+	}
+	a.rebuildBloom()
+
+	return removed, nil
+}
+
+func (a *AddressFile) Close() error {
+	return a.journal.Close()
+}
+
+// compact writes subscriptions as a single record in the order of List, so the order survives it.
+func (a *AddressFile) compact() error {
+	subscriptions := a.List()
+	if len(subscriptions) == 0 {
+		return a.journal.compact(nil)
+	}
+
+	record, err := newJournalRecord(journalOpAddressAddMany, subscriptions)
+	if err != nil {
+		return err
+	}
+
+	return a.journal.compact([]journalRecord{record})
+}
+
+func (a *AddressFile) replay(op string, raw json.RawMessage) error {
+	switch op {
+	case journalOpAddressAdd:
+		var subscription data.Subscription
+		if err := json.Unmarshal(raw, &subscription); err != nil {
+			return err
+		}
+
+		a.AddressInMemory.Add(subscription)
+	case journalOpAddressRemove:
+		var address string
+		if err := json.Unmarshal(raw, &address); err != nil {
+			return err
+		}
+
+		a.AddressInMemory.Remove(address)
+	case journalOpAddressAddMany:
+		var subscriptions []data.Subscription
+		if err := json.Unmarshal(raw, &subscriptions); err != nil {
+			return err
+		}
+
+		a.AddressInMemory.AddMany(subscriptions)
+	case journalOpAddressRemoveMany:
+		var addresses []string
+		if err := json.Unmarshal(raw, &addresses); err != nil {
+			return err
+		}
+
+		a.AddressInMemory.RemoveMany(addresses)
+	default:
+		return fmt.Errorf("unknown address journal operation %q", op)
+	}
+
+	return nil
+}
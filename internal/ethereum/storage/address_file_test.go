@@ -0,0 +1,125 @@
+package storage_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ethData "trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/storage"
+)
+
+func TestAddressFileRestore(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "addresses.jsonl")
+
+	data, err := storage.NewAddressFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data.Add(ethData.Subscription{Address: "any"})
+	assert.NoError(t, data.Close())
+
+	// act
+	restored, err := storage.NewAddressFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		assert.True(t, restored.Exists("any"))
+		assert.False(t, restored.Exists("another"))
+	}
+}
+
+func TestAddressFileRestoreRemoved(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "addresses.jsonl")
+
+	data, err := storage.NewAddressFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data.Add(ethData.Subscription{Address: "any", Label: "hot", Owner: "tenant", CreatedAtBlock: 5})
+	data.Add(ethData.Subscription{Address: "another"})
+	data.Remove("another")
+	assert.NoError(t, data.Close())
+
+	// act
+	restored, err := storage.NewAddressFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		assert.Equal(t, []ethData.Subscription{
+			{Address: "any", Label: "hot", Owner: "tenant", CreatedAtBlock: 5},
+		}, restored.List())
+	}
+}
+
+func TestAddressFileRestoreMany(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "addresses.jsonl")
+
+	data, err := storage.NewAddressFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data.AddMany([]ethData.Subscription{{Address: "a"}, {Address: "b"}, {Address: "c"}})
+	data.RemoveMany([]string{"a", "c"})
+	assert.NoError(t, data.Close())
+
+	// act
+	restored, err := storage.NewAddressFileWithConfig(path, storage.AddressConfig{BloomFilter: true})
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		assert.Equal(t, []ethData.Subscription{{Address: "b"}}, restored.List())
+		assert.False(t, restored.Exists("a"))
+	}
+}
+
+func TestAddressFileRestoreAfterCompaction(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "addresses.jsonl")
+
+	data, err := storage.NewAddressFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data.Add(ethData.Subscription{Address: "c", CreatedAtBlock: 3})
+	data.Add(ethData.Subscription{Address: "a", Label: "hot"})
+	data.Add(ethData.Subscription{Address: "b"})
+	data.Remove("b")
+	assert.NoError(t, data.Close())
+
+	compacted, err := storage.NewAddressFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, compacted.Close())
+
+	// act
+	restored, err := storage.NewAddressFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		assert.Equal(t, []ethData.Subscription{
+			{Address: "c", CreatedAtBlock: 3},
+			{Address: "a", Label: "hot"},
+		}, restored.List())
+	}
+
+	content, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, bytes.Count(content, []byte("\n")))
+	}
+}
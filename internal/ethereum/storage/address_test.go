@@ -1,17 +1,19 @@
 package storage_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	ethData "trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/storage"
 )
 
 func TestAddressExists(t *testing.T) {
 	// arrange
 	data := storage.NewAddressInMemory()
-	data.Add("any")
+	data.Add(ethData.Subscription{Address: "any"})
 
 	// act
 	result := data.Exists("any")
@@ -30,3 +32,188 @@ func TestAddressExistsNotExist(t *testing.T) {
 	// assert
 	assert.False(t, result)
 }
+
+func TestAddressAdd(t *testing.T) {
+	// arrange
+	data := storage.NewAddressInMemory()
+
+	// act
+	added, err := data.Add(ethData.Subscription{Address: "any", Label: "hot"})
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	// act
+	added, err = data.Add(ethData.Subscription{Address: "any", Label: "cold"})
+
+	// assert
+	assert.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, []ethData.Subscription{{Address: "any", Label: "hot"}}, data.List())
+}
+
+func TestAddressRemove(t *testing.T) {
+	// arrange
+	data := storage.NewAddressInMemory()
+	data.Add(ethData.Subscription{Address: "any"})
+
+	// act
+	removed, err := data.Remove("any")
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.False(t, data.Exists("any"))
+
+	removed, err = data.Remove("any")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestAddressList(t *testing.T) {
+	// arrange
+	data := storage.NewAddressInMemory()
+	data.Add(ethData.Subscription{Address: "b", CreatedAtBlock: 2})
+	data.Add(ethData.Subscription{Address: "c", CreatedAtBlock: 1, Label: "cold", Owner: "tenant"})
+	data.Add(ethData.Subscription{Address: "a", CreatedAtBlock: 2})
+	data.Remove("c")
+	data.Add(ethData.Subscription{Address: "c", CreatedAtBlock: 1, Label: "cold", Owner: "tenant"})
+
+	// act
+	result := data.List()
+
+	// assert
+	assert.Equal(t, []ethData.Subscription{
+		{Address: "b", CreatedAtBlock: 2},
+		{Address: "a", CreatedAtBlock: 2},
+		{Address: "c", CreatedAtBlock: 1, Label: "cold", Owner: "tenant"},
+	}, result)
+}
+
+func TestAddressAddMany(t *testing.T) {
+	// arrange
+	data := storage.NewAddressInMemory()
+	data.Add(ethData.Subscription{Address: "a", Label: "hot"})
+
+	// act
+	added, err := data.AddMany([]ethData.Subscription{
+		{Address: "a"},
+		{Address: "b"},
+		{Address: "b"},
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []ethData.Subscription{{Address: "b"}}, added)
+	assert.Equal(t, []ethData.Subscription{
+		{Address: "a", Label: "hot"},
+		{Address: "b"},
+	}, data.List())
+}
+
+func TestAddressRemoveMany(t *testing.T) {
+	// arrange
+	data := storage.NewAddressInMemory()
+	data.AddMany([]ethData.Subscription{{Address: "a"}, {Address: "b"}})
+
+	// act
+	removed, err := data.RemoveMany([]string{"a", "c"})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, removed)
+	assert.False(t, data.Exists("a"))
+	assert.True(t, data.Exists("b"))
+}
+
+func TestAddressBloomFilter(t *testing.T) {
+	// arrange
+	data := storage.NewAddressInMemoryWithConfig(storage.AddressConfig{
+		BloomFilter:   true,
+		BloomCapacity: 4,
+	})
+
+	// act
+	data.AddMany(subscriptions(0, 100))
+	data.Add(ethData.Subscription{Address: addressOf(100)})
+	data.RemoveMany([]string{addressOf(0), addressOf(1)})
+
+	// assert
+	for i := 2; i <= 100; i++ {
+		assert.True(t, data.Exists(addressOf(i)), addressOf(i))
+	}
+	assert.False(t, data.Exists(addressOf(0)))
+	assert.False(t, data.Exists(addressOf(1)))
+	assert.False(t, data.Exists(addressOf(101)))
+
+	// act
+	data.RemoveMany(addresses(2, 101))
+
+	// assert
+	assert.Empty(t, data.List())
+	assert.False(t, data.Exists(addressOf(50)))
+}
+
+// BenchmarkAddressExistsBlock measures matching of a block with 200 transactions, sender and
+// receiver of each are looked up. Cost per block must not grow with the number of subscribed addresses.
+func BenchmarkAddressExistsBlock(b *testing.B) {
+	const transactions = 200
+
+	for _, bloom := range []bool{false, true} {
+		for _, size := range []int{1_000, 10_000, 100_000, 200_000} {
+			b.Run(fmt.Sprintf("bloom=%t/addresses=%d", bloom, size), func(b *testing.B) {
+				data := storage.NewAddressInMemoryWithConfig(storage.AddressConfig{
+					BloomFilter: bloom,
+				})
+				data.AddMany(subscriptions(0, size))
+
+				// one transaction of block is sent to subscribed address, the others are not
+				block := addresses(size, size+2*transactions)
+				block[0] = addressOf(size / 2)
+
+				b.ResetTimer()
+				for range b.N {
+					for _, address := range block {
+						data.Exists(address)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkAddressAddMany(b *testing.B) {
+	for _, size := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("addresses=%d", size), func(b *testing.B) {
+			batch := subscriptions(0, size)
+
+			b.ResetTimer()
+			for range b.N {
+				storage.NewAddressInMemory().AddMany(batch)
+			}
+		})
+	}
+}
+
+func addressOf(i int) string {
+	return fmt.Sprintf("0x%040x", i)
+}
+
+func addresses(from, to int) []string {
+	result := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		result = append(result, addressOf(i))
+	}
+
+	return result
+}
+
+func subscriptions(from, to int) []ethData.Subscription {
+	result := make([]ethData.Subscription, 0, to-from)
+	for _, address := range addresses(from, to) {
+		result = append(result, ethData.Subscription{Address: address})
+	}
+
+	return result
+}
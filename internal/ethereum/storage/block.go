@@ -4,24 +4,39 @@ import (
 	"errors"
 	"sync"
 
+	"trust_walet/internal/ethereum/data"
+
 	"github.com/sirupsen/logrus"
 )
 
 type BlockInMemory struct {
-	data *int
-	mu   sync.RWMutex
+	data   *int
+	blocks map[int]data.Block
+	mu     sync.RWMutex
 }
 
-var ErrBlockCurrentNotSet = errors.New("current block is not set")
+var (
+	ErrBlockCurrentNotSet = errors.New("current block is not set")
+	ErrBlockNotFound      = errors.New("block is not found")
+)
 
 func NewBlockInMemory() *BlockInMemory {
-	return &BlockInMemory{}
+	return &BlockInMemory{
+		blocks: make(map[int]data.Block),
+	}
 }
 
-func (b *BlockInMemory) SetCurrentBlockNumber(value int) {
+func (b *BlockInMemory) SetCurrentBlockNumber(value int) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	b.setCurrent(value)
+
+	return nil
+}
+
+// setCurrent sets current block number, caller must hold mu.
+func (b *BlockInMemory) setCurrent(value int) {
 	logrus.
 		WithFields(logrus.Fields{
 			"block_number": value,
@@ -41,3 +56,62 @@ func (b *BlockInMemory) GetCurrentBlockNumber() (int, error) {
 
 	return *b.data, nil
 }
+
+func (b *BlockInMemory) SaveBlock(block *data.Block) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.blocks[block.Number] = *block
+
+	return nil
+}
+
+func (b *BlockInMemory) GetBlock(number int) (*data.Block, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	block, ok := b.blocks[number]
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+
+	return &block, nil
+}
+
+// DeleteBlocksFrom removes every stored block with number greater or equal to the given one.
+func (b *BlockInMemory) DeleteBlocksFrom(number int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.deleteFrom(number)
+
+	return nil
+}
+
+// deleteFrom removes blocks with number greater or equal to the given one, caller must hold mu.
+func (b *BlockInMemory) deleteFrom(number int) {
+	for n := range b.blocks {
+		if n >= number {
+			delete(b.blocks, n)
+		}
+	}
+}
+
+// DeleteBlocksBefore removes every stored block with number less than the given one.
+func (b *BlockInMemory) DeleteBlocksBefore(number int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.deleteBefore(number)
+
+	return nil
+}
+
+// deleteBefore removes blocks with number less than the given one, caller must hold mu.
+func (b *BlockInMemory) deleteBefore(number int) {
+	for n := range b.blocks {
+		if n < number {
+			delete(b.blocks, n)
+		}
+	}
+}
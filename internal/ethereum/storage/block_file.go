@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"trust_walet/internal/ethereum/data"
+)
+
+const (
+	journalOpBlockSetCurrent   = "set_current"
+	journalOpBlockSave         = "save"
+	journalOpBlockDeleteFrom   = "delete_from"
+	journalOpBlockDeleteBefore = "delete_before"
+)
+
+// BlockFile is BlockInMemory which survives restarts by keeping changes in a journal file.
+// Journal is compacted on open, so it holds only the current block and reorg window.
+type BlockFile struct {
+	*BlockInMemory
+
+	journal *journal
+}
+
+func NewBlockFile(path string) (*BlockFile, error) {
+	b := &BlockFile{
+		BlockInMemory: NewBlockInMemory(),
+	}
+
+	journal, err := openJournal(path, b.replay)
+	if err != nil {
+		return nil, err
+	}
+	b.journal = journal
+
+	if err := b.compact(); err != nil {
+		journal.Close()
+
+		return nil, fmt.Errorf("error compacting block journal: %w", err)
+	}
+
+	return b, nil
+}
+
+// SetCurrentBlockNumber journals the number before it is set, like every other change of BlockFile.
+func (b *BlockFile) SetCurrentBlockNumber(value int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.journal.append(journalOpBlockSetCurrent, value); err != nil {
+		return err
+	}
+	b.setCurrent(value)
+
+	return nil
+}
+
+func (b *BlockFile) SaveBlock(block *data.Block) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.journal.append(journalOpBlockSave, block); err != nil {
+		return err
+	}
+	b.blocks[block.Number] = *block
+
+	return nil
+}
+
+func (b *BlockFile) DeleteBlocksFrom(number int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.journal.append(journalOpBlockDeleteFrom, number); err != nil {
+		return err
+	}
+	b.deleteFrom(number)
+
+	return nil
+}
+
+func (b *BlockFile) DeleteBlocksBefore(number int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.journal.append(journalOpBlockDeleteBefore, number); err != nil {
+		return err
+	}
+	b.deleteBefore(number)
+
+	return nil
+}
+
+func (b *BlockFile) Close() error {
+	return b.journal.Close()
+}
+
+func (b *BlockFile) replay(op string, raw json.RawMessage) error {
+	switch op {
+	case journalOpBlockSave:
+		var block data.Block
+		if err := json.Unmarshal(raw, &block); err != nil {
+			return err
+		}
+
+		b.BlockInMemory.SaveBlock(&block)
+	case journalOpBlockSetCurrent:
+		var number int
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return err
+		}
+
+		b.BlockInMemory.SetCurrentBlockNumber(number)
+	case journalOpBlockDeleteFrom:
+		var number int
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return err
+		}
+
+		b.BlockInMemory.DeleteBlocksFrom(number)
+	case journalOpBlockDeleteBefore:
+		var number int
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return err
+		}
+
+		b.BlockInMemory.DeleteBlocksBefore(number)
+	default:
+		return fmt.Errorf("unknown block journal operation %q", op)
+	}
+
+	return nil
+}
+
+func (b *BlockFile) compact() error {
+	b.mu.RLock()
+
+	var records []journalRecord
+	for _, block := range b.blocks {
+		record, err := newJournalRecord(journalOpBlockSave, block)
+		if err != nil {
+			b.mu.RUnlock()
+
+			return err
+		}
+		records = append(records, record)
+	}
+
+	if b.data != nil {
+		record, err := newJournalRecord(journalOpBlockSetCurrent, *b.data)
+		if err != nil {
+			b.mu.RUnlock()
+
+			return err
+		}
+		records = append(records, record)
+	}
+
+	b.mu.RUnlock()
+
+	return b.journal.compact(records)
+}
@@ -0,0 +1,84 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ethData "trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/storage"
+)
+
+func TestBlockFileRestore(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+
+	data, err := storage.NewBlockFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 1; i <= 3; i++ {
+		data.SaveBlock(&ethData.Block{Number: i, Hash: "hash"})
+		data.SetCurrentBlockNumber(i)
+	}
+	data.DeleteBlocksBefore(2)
+	data.DeleteBlocksFrom(3)
+	assert.NoError(t, data.Close())
+
+	// act
+	restored, err := storage.NewBlockFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		current, err := restored.GetCurrentBlockNumber()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, current)
+
+		_, err = restored.GetBlock(1)
+		assert.ErrorIs(t, err, storage.ErrBlockNotFound)
+		_, err = restored.GetBlock(2)
+		assert.NoError(t, err)
+		_, err = restored.GetBlock(3)
+		assert.ErrorIs(t, err, storage.ErrBlockNotFound)
+	}
+}
+
+func TestBlockFileRestoreIncompleteRecord(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+	content := `{"op":"set_current","data":5}` + "\n" + `{"op":"set_cur`
+	if !assert.NoError(t, os.WriteFile(path, []byte(content), 0o644)) {
+		return
+	}
+
+	// act
+	restored, err := storage.NewBlockFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		current, err := restored.GetCurrentBlockNumber()
+		assert.NoError(t, err)
+		assert.Equal(t, 5, current)
+	}
+}
+
+func TestBlockFileRestoreCorrupted(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+	content := `{"op":"set_cur` + "\n" + `{"op":"set_current","data":5}` + "\n"
+	if !assert.NoError(t, os.WriteFile(path, []byte(content), 0o644)) {
+		return
+	}
+
+	// act
+	_, err := storage.NewBlockFile(path)
+
+	// assert
+	assert.ErrorIs(t, err, storage.ErrJournalCorrupted)
+}
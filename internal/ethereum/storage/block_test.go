@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	ethData "trust_walet/internal/ethereum/data"
 	"trust_walet/internal/ethereum/storage"
 )
 
@@ -32,3 +33,66 @@ func TestBlockInMemorSetGetCurrentBlock(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, result, 10)
 }
+
+func TestBlockInMemoryGetBlockNotFound(t *testing.T) {
+	// arrange
+	data := storage.NewBlockInMemory()
+
+	// act
+	result, err := data.GetBlock(10)
+
+	// assert
+	assert.ErrorIs(t, err, storage.ErrBlockNotFound)
+	assert.Nil(t, result)
+}
+
+func TestBlockInMemorySaveGetBlock(t *testing.T) {
+	// arrange
+	data := storage.NewBlockInMemory()
+	data.SaveBlock(&ethData.Block{Number: 10, Hash: "hash", ParentHash: "parent"})
+
+	// act
+	result, err := data.GetBlock(10)
+
+	// assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hash", result.Hash)
+		assert.Equal(t, "parent", result.ParentHash)
+	}
+}
+
+func TestBlockInMemoryDeleteBlocksFrom(t *testing.T) {
+	// arrange
+	data := storage.NewBlockInMemory()
+	for i := 1; i <= 3; i++ {
+		data.SaveBlock(&ethData.Block{Number: i})
+	}
+
+	// act
+	data.DeleteBlocksFrom(2)
+
+	// assert
+	_, err := data.GetBlock(1)
+	assert.NoError(t, err)
+	_, err = data.GetBlock(2)
+	assert.ErrorIs(t, err, storage.ErrBlockNotFound)
+	_, err = data.GetBlock(3)
+	assert.ErrorIs(t, err, storage.ErrBlockNotFound)
+}
+
+func TestBlockInMemoryDeleteBlocksBefore(t *testing.T) {
+	// arrange
+	data := storage.NewBlockInMemory()
+	for i := 1; i <= 3; i++ {
+		data.SaveBlock(&ethData.Block{Number: i})
+	}
+
+	// act
+	data.DeleteBlocksBefore(3)
+
+	// assert
+	_, err := data.GetBlock(2)
+	assert.ErrorIs(t, err, storage.ErrBlockNotFound)
+	_, err = data.GetBlock(3)
+	assert.NoError(t, err)
+}
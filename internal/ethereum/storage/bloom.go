@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// bloomFilter answers whether key may be in set, false answer is always right. It is used
+// to reject most of not subscribed addresses without touching the map of subscriptions.
+// Keys can not be removed, removed ones are counted and the filter is rebuilt when they pile up.
+type bloomFilter struct {
+	bits     []uint64
+	hashes   int
+	capacity int
+	rate     float64
+	seed     maphash.Seed
+	count    int
+	removed  int
+}
+
+func newBloomFilter(capacity int, rate float64) *bloomFilter {
+	capacity = max(capacity, 1)
+
+	// optimal size and number of hashes for capacity keys and false positive rate
+	size := int(math.Ceil(-float64(capacity) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	size = max(size, 64)
+	hashes := max(int(math.Round(float64(size)/float64(capacity)*math.Ln2)), 1)
+
+	return &bloomFilter{
+		bits:     make([]uint64, (size+63)/64),
+		hashes:   hashes,
+		capacity: capacity,
+		rate:     rate,
+		seed:     maphash.MakeSeed(),
+	}
+}
+
+func (f *bloomFilter) add(key string) {
+	h1, h2 := f.hash(key)
+	size := uint64(len(f.bits) * 64)
+	for i := range f.hashes {
+		bit := (h1 + uint64(i)*h2) % size
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+func (f *bloomFilter) mayContain(key string) bool {
+	h1, h2 := f.hash(key)
+	size := uint64(len(f.bits) * 64)
+	for i := range f.hashes {
+		bit := (h1 + uint64(i)*h2) % size
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *bloomFilter) remove() {
+	f.removed++
+}
+
+// stale tells whether filter must be rebuilt, because it is over capacity and its false
+// positive rate grows, or because most of its keys were removed.
+func (f *bloomFilter) stale() bool {
+	return f.count > f.capacity || (f.removed > 0 && f.removed >= f.count-f.removed)
+}
+
+// hash returns two hashes which are combined into the hashes of filter, they are halves
+// of a single 64 bit hash, so key is hashed once.
+func (f *bloomFilter) hash(key string) (uint64, uint64) {
+	h := maphash.String(f.seed, key)
+
+	return h & math.MaxUint32, h>>32 | 1
+}
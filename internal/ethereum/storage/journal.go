@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	journalRecord struct {
+		Op   string          `json:"op"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	// journal is an append-only file of JSON lines which is replayed on open.
+	journal struct {
+		path string
+		file *os.File
+
+		mu sync.Mutex
+	}
+)
+
+var ErrJournalCorrupted = errors.New("journal file is corrupted")
+
+// openJournal replays every record of the file at path and opens it for appending.
+// A broken last line is a write interrupted by crash, so it is cut off instead of failing.
+func openJournal(path string, replay func(op string, data json.RawMessage) error) (*journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal %s: %w", path, err)
+	}
+
+	valid, err := replayJournal(file, replay)
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("error replaying journal %s: %w", path, err)
+	}
+
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("error truncating journal %s: %w", path, err)
+	}
+
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("error seeking journal %s: %w", path, err)
+	}
+
+	return &journal{
+		path: path,
+		file: file,
+	}, nil
+}
+
+func replayJournal(file *os.File, replay func(op string, data json.RawMessage) error) (int64, error) {
+	reader := bufio.NewReader(file)
+
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				logrus.
+					WithFields(logrus.Fields{
+						"file":   file.Name(),
+						"offset": offset,
+					}).
+					Warn("Incomplete journal record was dropped")
+			}
+
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, fmt.Errorf("%w: offset %d: %w", ErrJournalCorrupted, offset, err)
+		}
+
+		if err := replay(record.Op, record.Data); err != nil {
+			return 0, fmt.Errorf("%w: offset %d: %w", ErrJournalCorrupted, offset, err)
+		}
+
+		offset += int64(len(line))
+	}
+}
+
+// append writes record before the change it describes is applied in memory, callers hold
+// the lock of their storage meanwhile, so records are in the order of changes.
+func (j *journal) append(op string, value interface{}) error {
+	if err := j.write(op, value); err != nil {
+		return fmt.Errorf("error appending %s record to journal %s: %w", op, j.path, err)
+	}
+
+	return nil
+}
+
+func (j *journal) write(op string, value interface{}) error {
+	line, err := encodeJournalRecord(op, value)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("error writing journal record: %w", err)
+	}
+
+	return j.file.Sync()
+}
+
+// compact replaces journal content with the given records which must describe the same state.
+func (j *journal) compact(records []journalRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating journal snapshot: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			tmp.Close()
+
+			return fmt.Errorf("error encoding journal snapshot: %w", err)
+		}
+
+		writer.Write(append(line, '\n'))
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("error writing journal snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("error syncing journal snapshot: %w", err)
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("error replacing journal with snapshot: %w", err)
+	}
+
+	j.file.Close()
+
+	j.file, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error reopening journal: %w", err)
+	}
+
+	return nil
+}
+
+func (j *journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+func newJournalRecord(op string, value interface{}) (journalRecord, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return journalRecord{}, fmt.Errorf("error encoding journal record: %w", err)
+	}
+
+	return journalRecord{Op: op, Data: data}, nil
+}
+
+func encodeJournalRecord(op string, value interface{}) ([]byte, error) {
+	record, err := newJournalRecord(op, value)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding journal record: %w", err)
+	}
+
+	return append(line, '\n'), nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"slices"
 	"sync"
 
 	"trust_walet/internal/ethereum/data"
@@ -8,51 +9,298 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-type TransactionInMemory struct {
-	data map[string][]data.Transaction
+type (
+	transactionRecord struct {
+		transaction data.Transaction
+		delivered   bool
+	}
 
-	mu sync.RWMutex
-}
+	addressTransactions struct {
+		// records are ordered by position in chain, pointers keep shifting cheap when
+		// older transaction is inserted, e.g. by history backfill
+		records []*transactionRecord
+		// ids indexes records by data.Transaction.ID
+		ids map[string]*transactionRecord
+		// undelivered is position of the first record which may be not delivered yet,
+		// nil when every record was delivered
+		undelivered *data.TransactionPosition
+	}
+
+	// TransactionInMemory indexes transactions of address by id and by position, so lookup
+	// of a transfer is constant time and ranges of blocks are found by binary search.
+	TransactionInMemory struct {
+		data map[string]*addressTransactions
+		// blocks indexes addresses which have transactions in block, retraction of block
+		// touches only them
+		blocks map[int]map[string]struct{}
+
+		mu sync.RWMutex
+	}
+)
 
 func NewTransactionInMemory() *TransactionInMemory {
 	return &TransactionInMemory{
-		data: make(map[string][]data.Transaction),
+		data:   make(map[string]*addressTransactions),
+		blocks: make(map[int]map[string]struct{}),
 	}
 }
 
-func (t *TransactionInMemory) SaveForAddress(address string, transaction *data.Transaction) {
+// SaveForAddress returns false when transfer with the same id is already saved for address.
+func (t *TransactionInMemory) SaveForAddress(address string, transaction *data.Transaction) (bool, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.data[address] = append(t.data[address], *transaction)
+	return t.insert(address, transactionRecord{transaction: *transaction}), nil
+}
+
+// insert keeps records ordered by position, transfer which is already saved is skipped,
+// so blocks scanned twice, e.g. by history backfill and live processing, produce no duplicates.
+// Caller must hold mu.
+func (t *TransactionInMemory) insert(address string, record transactionRecord) bool {
+	items, ok := t.data[address]
+	if !ok {
+		items = &addressTransactions{
+			ids: make(map[string]*transactionRecord),
+		}
+		t.data[address] = items
+	}
+
+	id := record.transaction.ID()
+	if _, ok := items.ids[id]; ok {
+		return false
+	}
+
+	position := record.transaction.Position()
+	i := items.search(position, 1)
+	items.records = slices.Insert(items.records, i, &record)
+	items.ids[id] = &record
+
+	if !record.delivered && (items.undelivered == nil || position.Compare(*items.undelivered) < 0) {
+		items.undelivered = &position
+	}
+
+	number := record.transaction.BlockNumber
+	if _, ok := t.blocks[number]; !ok {
+		t.blocks[number] = make(map[string]struct{})
+	}
+	t.blocks[number][address] = struct{}{}
+
+	return true
 }
 
-func (t *TransactionInMemory) Exists(address, hash string) bool {
+// Exists tells whether transfer with the given id, see data.Transaction.ID, is saved for address.
+func (t *TransactionInMemory) Exists(address, id string) bool {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	for _, tx := range t.data[address] {
-		if tx.Hash == hash {
+	if t.saved(address, id) {
+		return true
+	}
+
+	// it is called for every matched transfer, fields are not built when not logged
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.
+			WithFields(logrus.Fields{
+				"address": address,
+				"id":      id,
+			}).
+			Debug("Address and transfer id were not found in storage")
+	}
+
+	return false
+}
+
+// saved tells whether transfer is saved for address without logging, caller must hold mu.
+func (t *TransactionInMemory) saved(address, id string) bool {
+	if items, ok := t.data[address]; ok {
+		if _, ok := items.ids[id]; ok {
 			return true
 		}
 	}
 
+	return false
+}
+
+// FetchAllByAddress returns once transactions of address included up to the given block.
+// Transactions are marked as delivered and stay in storage for FindByAddress.
+func (t *TransactionInMemory) FetchAllByAddress(address string, toBlock int) ([]data.Transaction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.deliver(address, toBlock, nil)
+}
+
+// deliver marks transactions of address included up to the given block as delivered and returns them.
+// When write is set, ids of the transactions are passed to it first and nothing is marked if it fails.
+// Caller must hold mu.
+func (t *TransactionInMemory) deliver(address string, toBlock int, write func(ids []string) error) ([]data.Transaction, error) {
+	items, ok := t.data[address]
+	if !ok || items.undelivered == nil {
+		return nil, nil
+	}
+
+	// records before the first undelivered one are not visited
+	var records []*transactionRecord
+	i := items.search(*items.undelivered, 0)
+	for ; i < len(items.records) && items.records[i].transaction.BlockNumber <= toBlock; i++ {
+		if !items.records[i].delivered {
+			records = append(records, items.records[i])
+		}
+	}
+
+	if write != nil && len(records) > 0 {
+		ids := make([]string, len(records))
+		for j, r := range records {
+			ids[j] = r.transaction.ID()
+		}
+
+		if err := write(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	var transactions []data.Transaction
+	for _, r := range records {
+		transactions = append(transactions, r.transaction)
+		r.delivered = true
+	}
+
+	items.undelivered = nil
+	if i < len(items.records) {
+		position := items.records[i].transaction.Position()
+		items.undelivered = &position
+	}
+
+	return transactions, nil
+}
+
+// markDelivered marks transactions of address with the given ids as delivered. Position of the first
+// undelivered record is kept, records delivered after it are skipped by the next deliver.
+// Caller must hold mu.
+func (t *TransactionInMemory) markDelivered(address string, ids []string) {
+	items, ok := t.data[address]
+	if !ok {
+		return
+	}
+
+	for _, id := range ids {
+		if r, ok := items.ids[id]; ok {
+			r.delivered = true
+		}
+	}
+}
+
+// FindByAddress returns transactions of address matching the filter without changing them.
+func (t *TransactionInMemory) FindByAddress(address string, filter data.TransactionFilter) []data.Transaction {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	items, ok := t.data[address]
+	if !ok {
+		return nil
+	}
+
+	i := items.search(data.TransactionPosition{BlockNumber: filter.FromBlock}, 0)
+	if filter.After != nil {
+		i = max(i, items.search(*filter.After, 1))
+	}
+
+	var transactions []data.Transaction
+	for ; i < len(items.records); i++ {
+		if filter.Limit > 0 && len(transactions) == filter.Limit {
+			break
+		}
+
+		r := items.records[i]
+		if r.transaction.BlockNumber > filter.ToBlock {
+			break
+		}
+
+		if filter.Match(address, &r.transaction) {
+			transactions = append(transactions, r.transaction)
+		}
+	}
+
+	return transactions
+}
+
+// DeleteByBlockNumber removes transactions of the given block for every address,
+// it returns the removed ones by address.
+func (t *TransactionInMemory) DeleteByBlockNumber(number int) (map[string][]data.Transaction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.deleteBlock(number), nil
+}
+
+// deleteBlock removes transactions of the given block and returns them, caller must hold mu.
+func (t *TransactionInMemory) deleteBlock(number int) map[string][]data.Transaction {
+	removed := make(map[string][]data.Transaction, len(t.blocks[number]))
+	for address := range t.blocks[number] {
+		items := t.data[address]
+
+		from := items.search(data.TransactionPosition{BlockNumber: number}, 0)
+		to := items.search(data.TransactionPosition{BlockNumber: number + 1}, 0)
+		for _, r := range items.records[from:to] {
+			delete(items.ids, r.transaction.ID())
+			removed[address] = append(removed[address], r.transaction)
+		}
+		items.records = slices.Delete(items.records, from, to)
+
+		if len(items.records) == 0 {
+			delete(t.data, address)
+		}
+	}
+	delete(t.blocks, number)
+
 	logrus.
 		WithFields(logrus.Fields{
-			"address": address,
-			"hash":    hash,
+			"block_number": number,
 		}).
-		Debug("Address and hash were not found in storage")
+		Debug("Block transactions were deleted from storage")
 
-	return false
+	return removed
 }
 
-func (t *TransactionInMemory) FetchAllByAddress(address string) []data.Transaction {
+// DeleteByAddress removes every transaction of address.
+func (t *TransactionInMemory) DeleteByAddress(address string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	transactions := t.data[address]
-	t.data[address] = nil
+	t.deleteAddress(address)
 
-	return transactions
+	return nil
+}
+
+// deleteAddress removes every transaction of address, caller must hold mu.
+func (t *TransactionInMemory) deleteAddress(address string) {
+	if items, ok := t.data[address]; ok {
+		for _, r := range items.records {
+			addresses := t.blocks[r.transaction.BlockNumber]
+			delete(addresses, address)
+			if len(addresses) == 0 {
+				delete(t.blocks, r.transaction.BlockNumber)
+			}
+		}
+	}
+	delete(t.data, address)
+
+	logrus.
+		WithFields(logrus.Fields{
+			"address": address,
+		}).
+		Debug("Address transactions were deleted from storage")
+}
+
+// search returns index of the first record after position, bias 0 includes the record at position.
+func (a *addressTransactions) search(position data.TransactionPosition, bias int) int {
+	i, _ := slices.BinarySearchFunc(a.records, position, func(r *transactionRecord, p data.TransactionPosition) int {
+		if r.transaction.Position().Compare(p) < bias {
+			return -1
+		}
+
+		return 1
+	})
+
+	return i
 }
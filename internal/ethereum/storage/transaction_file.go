@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"trust_walet/internal/ethereum/data"
+)
+
+const (
+	journalOpTransactionSave        = "save"
+	journalOpTransactionDeliver     = "deliver"
+	journalOpTransactionDeleteBlock = "delete_block"
+	journalOpTransactionDeleteAddr  = "delete_address"
+)
+
+type (
+	transactionJournalSave struct {
+		Address     string
+		Transaction data.Transaction
+		Delivered   bool `json:",omitempty"`
+	}
+
+	transactionJournalDeliver struct {
+		Address string
+		IDs     []string
+	}
+
+	// TransactionFile is TransactionInMemory which survives restarts by keeping changes in a journal file.
+	TransactionFile struct {
+		*TransactionInMemory
+
+		journal *journal
+	}
+)
+
+func NewTransactionFile(path string) (*TransactionFile, error) {
+	t := &TransactionFile{
+		TransactionInMemory: NewTransactionInMemory(),
+	}
+
+	journal, err := openJournal(path, t.replay)
+	if err != nil {
+		return nil, err
+	}
+	t.journal = journal
+
+	if err := t.compact(); err != nil {
+		journal.Close()
+
+		return nil, fmt.Errorf("error compacting transaction journal: %w", err)
+	}
+
+	return t, nil
+}
+
+// SaveForAddress journals transaction before it is saved, nothing is saved when journal write fails.
+func (t *TransactionFile) SaveForAddress(address string, transaction *data.Transaction) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.saved(address, transaction.ID()) {
+		return false, nil
+	}
+
+	err := t.journal.append(journalOpTransactionSave, transactionJournalSave{
+		Address:     address,
+		Transaction: *transaction,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return t.insert(address, transactionRecord{transaction: *transaction}), nil
+}
+
+// FetchAllByAddress journals ids of delivered transactions, so exactly them are not delivered again
+// after restart. Transactions stay undelivered when journal write fails.
+func (t *TransactionFile) FetchAllByAddress(address string, toBlock int) ([]data.Transaction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.deliver(address, toBlock, func(ids []string) error {
+		return t.journal.append(journalOpTransactionDeliver, transactionJournalDeliver{
+			Address: address,
+			IDs:     ids,
+		})
+	})
+}
+
+func (t *TransactionFile) DeleteByBlockNumber(number int) (map[string][]data.Transaction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.journal.append(journalOpTransactionDeleteBlock, number); err != nil {
+		return nil, err
+	}
+
+	return t.deleteBlock(number), nil
+}
+
+func (t *TransactionFile) DeleteByAddress(address string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.journal.append(journalOpTransactionDeleteAddr, address); err != nil {
+		return err
+	}
+	t.deleteAddress(address)
+
+	return nil
+}
+
+func (t *TransactionFile) Close() error {
+	return t.journal.Close()
+}
+
+func (t *TransactionFile) replay(op string, raw json.RawMessage) error {
+	switch op {
+	case journalOpTransactionSave:
+		var value transactionJournalSave
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+
+		t.TransactionInMemory.insert(value.Address, transactionRecord{
+			transaction: value.Transaction,
+			delivered:   value.Delivered,
+		})
+	case journalOpTransactionDeliver:
+		var value transactionJournalDeliver
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+
+		t.markDelivered(value.Address, value.IDs)
+	case journalOpTransactionDeleteBlock:
+		var number int
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return err
+		}
+
+		t.TransactionInMemory.DeleteByBlockNumber(number)
+	case journalOpTransactionDeleteAddr:
+		var address string
+		if err := json.Unmarshal(raw, &address); err != nil {
+			return err
+		}
+
+		t.TransactionInMemory.DeleteByAddress(address)
+	default:
+		return fmt.Errorf("unknown transaction journal operation %q", op)
+	}
+
+	return nil
+}
+
+func (t *TransactionFile) compact() error {
+	t.mu.RLock()
+
+	var records []journalRecord
+	for address, items := range t.data {
+		for _, item := range items.records {
+			record, err := newJournalRecord(journalOpTransactionSave, transactionJournalSave{
+				Address:     address,
+				Transaction: item.transaction,
+				Delivered:   item.delivered,
+			})
+			if err != nil {
+				t.mu.RUnlock()
+
+				return err
+			}
+			records = append(records, record)
+		}
+	}
+
+	t.mu.RUnlock()
+
+	return t.journal.compact(records)
+}
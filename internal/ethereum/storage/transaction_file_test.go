@@ -0,0 +1,161 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trust_walet/internal/ethereum/data"
+	"trust_walet/internal/ethereum/storage"
+)
+
+func TestTransactionFileRestore(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+
+	store, err := storage.NewTransactionFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 2, Hash: "hash2"})
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 3, Hash: "hash3"})
+	store.FetchAllByAddress("addr1", 1)
+	store.DeleteByBlockNumber(3)
+	assert.NoError(t, store.Close())
+
+	// act
+	restored, err := storage.NewTransactionFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		assert.True(t, restored.Exists("addr1", "hash1"))
+		assert.False(t, restored.Exists("addr1", "hash3"))
+
+		tx, err := restored.FetchAllByAddress("addr1", 10)
+		assert.NoError(t, err)
+		if assert.Len(t, tx, 1) {
+			assert.Equal(t, "hash2", tx[0].Hash)
+		}
+	}
+}
+
+func TestTransactionFileRestoreAfterCompaction(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+
+	store, err := storage.NewTransactionFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 2, Hash: "hash2"})
+	store.FetchAllByAddress("addr1", 1)
+	assert.NoError(t, store.Close())
+
+	compacted, err := storage.NewTransactionFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, compacted.Close())
+
+	// act
+	restored, err := storage.NewTransactionFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		tx, err := restored.FetchAllByAddress("addr1", 10)
+		assert.NoError(t, err)
+		if assert.Len(t, tx, 1) {
+			assert.Equal(t, "hash2", tx[0].Hash)
+		}
+	}
+}
+
+func TestTransactionFileRestoreDeletedAddress(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+
+	store, err := storage.NewTransactionFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	store.SaveForAddress("addr2", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	store.DeleteByAddress("addr1")
+	assert.NoError(t, store.Close())
+
+	// act
+	restored, err := storage.NewTransactionFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		assert.False(t, restored.Exists("addr1", "hash1"))
+		assert.True(t, restored.Exists("addr2", "hash1"))
+	}
+}
+
+func TestTransactionFileRestoreDeliveredIDs(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+
+	store, err := storage.NewTransactionFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 5, Hash: "hash5"})
+	store.FetchAllByAddress("addr1", 5)
+	// older transaction saved after delivery, e.g. by history backfill
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 2, Hash: "hash2"})
+	assert.NoError(t, store.Close())
+
+	// act
+	restored, err := storage.NewTransactionFile(path)
+
+	// assert
+	if assert.NoError(t, err) {
+		defer restored.Close()
+
+		tx, err := restored.FetchAllByAddress("addr1", 10)
+		assert.NoError(t, err)
+		if assert.Len(t, tx, 1) {
+			assert.Equal(t, "hash2", tx[0].Hash)
+		}
+	}
+}
+
+func TestTransactionFileJournalError(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+
+	store, err := storage.NewTransactionFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	// journal file is closed, so every write fails
+	assert.NoError(t, store.Close())
+
+	// act
+	saved, saveErr := store.SaveForAddress("addr1", &data.Transaction{BlockNumber: 2, Hash: "hash2"})
+	tx, fetchErr := store.FetchAllByAddress("addr1", 10)
+	deleteErr := store.DeleteByAddress("addr1")
+
+	// assert
+	assert.Error(t, saveErr)
+	assert.False(t, saved)
+	assert.False(t, store.Exists("addr1", "hash2"))
+
+	assert.Error(t, fetchErr)
+	assert.Empty(t, tx)
+
+	assert.Error(t, deleteErr)
+	assert.True(t, store.Exists("addr1", "hash1"))
+}
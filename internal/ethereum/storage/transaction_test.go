@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,9 +21,10 @@ func TestInMemoryFetchAllByAddressSaveAndFetch(t *testing.T) {
 	})
 
 	// act
-	tx := storage.FetchAllByAddress("addr1")
+	tx, err := storage.FetchAllByAddress("addr1", 0)
 
 	// assert
+	assert.NoError(t, err)
 	assert.NotEmpty(t, tx)
 	assert.Len(t, tx, 1)
 }
@@ -38,16 +40,18 @@ func TestInMemoryFetchAllByAddressSaveAndFetchTwice(t *testing.T) {
 	})
 
 	// act
-	tx1 := storage.FetchAllByAddress("addr1")
+	tx1, err := storage.FetchAllByAddress("addr1", 0)
 
 	// assert
+	assert.NoError(t, err)
 	assert.NotEmpty(t, tx1)
 	assert.Len(t, tx1, 1)
 
 	// act
-	tx2 := storage.FetchAllByAddress("addr1")
+	tx2, err := storage.FetchAllByAddress("addr1", 0)
 
 	// assert
+	assert.NoError(t, err)
 	assert.Empty(t, tx2)
 }
 
@@ -56,9 +60,10 @@ func TestInMemoryFetchAllByAddressAddressNotExist(t *testing.T) {
 	storage := storage.NewTransactionInMemory()
 
 	// act
-	tx := storage.FetchAllByAddress("addr1")
+	tx, err := storage.FetchAllByAddress("addr1", 0)
 
 	// assert
+	assert.NoError(t, err)
 	assert.Empty(t, tx)
 }
 
@@ -106,3 +111,296 @@ func TestInMemoryExists(t *testing.T) {
 	// assert
 	assert.True(t, result)
 }
+
+func TestInMemoryExistsTransfer(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{
+		Kind:       data.TransferKindERC20,
+		Hash:       "hash",
+		EventIndex: 3,
+	})
+	storage.SaveForAddress("addr1", &data.Transaction{
+		Hash: "hash",
+	})
+
+	// act
+	tx := storage.FindByAddress("addr1", data.TransactionFilter{ToBlock: 1})
+
+	// assert
+	assert.True(t, storage.Exists("addr1", "hash"))
+	assert.True(t, storage.Exists("addr1", "hash:erc20:3"))
+	assert.False(t, storage.Exists("addr1", "hash:erc20:4"))
+	if assert.Len(t, tx, 2) {
+		assert.Equal(t, data.TransferKindNative, tx[0].Kind)
+		assert.Equal(t, data.TransferKindERC20, tx[1].Kind)
+	}
+}
+
+func TestInMemoryFindByAddressKinds(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{Hash: "hash1"})
+	storage.SaveForAddress("addr1", &data.Transaction{Hash: "hash2", Kind: data.TransferKindERC721})
+	storage.SaveForAddress("addr1", &data.Transaction{Hash: "hash3", Kind: data.TransferKindERC1155})
+
+	// act
+	tx := storage.FindByAddress("addr1", data.TransactionFilter{
+		Kinds: []data.TransferKind{data.TransferKindERC721, data.TransferKindERC1155},
+	})
+
+	// assert
+	if assert.Len(t, tx, 2) {
+		assert.Equal(t, "hash2", tx[0].Hash)
+		assert.Equal(t, "hash3", tx[1].Hash)
+	}
+}
+
+func TestInMemoryDeleteByBlockNumber(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber: 1,
+		Hash:        "hash1",
+	})
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber: 2,
+		Hash:        "hash2",
+	})
+
+	// act
+	removed, err := storage.DeleteByBlockNumber(2)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]data.Transaction{
+		"addr1": {{BlockNumber: 2, Hash: "hash2"}},
+	}, removed)
+	assert.True(t, storage.Exists("addr1", "hash1"))
+	assert.False(t, storage.Exists("addr1", "hash2"))
+}
+
+func TestInMemoryFetchAllByAddressToBlock(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber: 1,
+		Hash:        "hash1",
+	})
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber: 2,
+		Hash:        "hash2",
+	})
+
+	// act
+	tx, err := storage.FetchAllByAddress("addr1", 1)
+
+	// assert
+	assert.NoError(t, err)
+	if assert.Len(t, tx, 1) {
+		assert.Equal(t, "hash1", tx[0].Hash)
+	}
+
+	tx, err = storage.FetchAllByAddress("addr1", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, tx)
+	assert.True(t, storage.Exists("addr1", "hash1"))
+}
+
+func TestInMemoryFindByAddress(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber: 2,
+		Hash:        "hash3",
+		From:        "addr1",
+	})
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber: 1,
+		Hash:        "hash1",
+		To:          "addr1",
+	})
+	storage.SaveForAddress("addr1", &data.Transaction{
+		BlockNumber:      1,
+		TransactionIndex: 1,
+		Hash:             "hash2",
+		To:               "addr1",
+	})
+	storage.FetchAllByAddress("addr1", 2)
+
+	testCases := map[string]struct {
+		filter   data.TransactionFilter
+		expected []string
+	}{
+		"all": {
+			filter:   data.TransactionFilter{ToBlock: 2},
+			expected: []string{"hash1", "hash2", "hash3"},
+		},
+		"block range": {
+			filter:   data.TransactionFilter{FromBlock: 2, ToBlock: 2},
+			expected: []string{"hash3"},
+		},
+		"incoming": {
+			filter:   data.TransactionFilter{ToBlock: 2, Direction: data.DirectionIncoming},
+			expected: []string{"hash1", "hash2"},
+		},
+		"outgoing": {
+			filter:   data.TransactionFilter{ToBlock: 2, Direction: data.DirectionOutgoing},
+			expected: []string{"hash3"},
+		},
+		"after position": {
+			filter:   data.TransactionFilter{ToBlock: 2, After: &data.TransactionPosition{BlockNumber: 1}},
+			expected: []string{"hash2", "hash3"},
+		},
+		"limit": {
+			filter:   data.TransactionFilter{ToBlock: 2, Limit: 1},
+			expected: []string{"hash1"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// act
+			tx := storage.FindByAddress("addr1", tc.filter)
+
+			// assert
+			hashes := make([]string, 0, len(tx))
+			for _, item := range tx {
+				hashes = append(hashes, item.Hash)
+			}
+			assert.Equal(t, tc.expected, hashes)
+		})
+	}
+}
+
+func TestInMemorySaveForAddressDuplicate(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	transfer := &data.Transaction{
+		Kind:        data.TransferKindERC20,
+		BlockNumber: 1,
+		Hash:        "hash",
+		EventIndex:  2,
+	}
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash"})
+	storage.SaveForAddress("addr1", transfer)
+
+	// act
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash"})
+	storage.SaveForAddress("addr1", transfer)
+
+	// assert
+	assert.Len(t, storage.FindByAddress("addr1", data.TransactionFilter{ToBlock: 1}), 2)
+}
+
+func TestInMemoryFetchAllByAddressOlderSavedLater(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 5, Hash: "hash5"})
+	storage.FetchAllByAddress("addr1", 5)
+
+	// act
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 2, Hash: "hash2"})
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 6, Hash: "hash6"})
+	tx, err := storage.FetchAllByAddress("addr1", 5)
+
+	// assert
+	assert.NoError(t, err)
+	if assert.Len(t, tx, 1) {
+		assert.Equal(t, "hash2", tx[0].Hash)
+	}
+
+	tx, err = storage.FetchAllByAddress("addr1", 6)
+	assert.NoError(t, err)
+	if assert.Len(t, tx, 1) {
+		assert.Equal(t, "hash6", tx[0].Hash)
+	}
+}
+
+func TestInMemoryDeleteByBlockNumberAfterDeleteByAddress(t *testing.T) {
+	// arrange
+	storage := storage.NewTransactionInMemory()
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	storage.SaveForAddress("addr2", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+	storage.DeleteByAddress("addr1")
+
+	// act
+	storage.DeleteByBlockNumber(1)
+
+	// assert
+	assert.False(t, storage.Exists("addr1", "hash1"))
+	assert.False(t, storage.Exists("addr2", "hash1"))
+
+	// act
+	storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: 1, Hash: "hash1"})
+
+	// assert
+	assert.True(t, storage.Exists("addr1", "hash1"))
+}
+
+// BenchmarkInMemoryHotAddress measures operations on an address which received many transactions,
+// their cost must not grow with the number of transactions.
+func BenchmarkInMemoryHotAddress(b *testing.B) {
+	for _, size := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("Exists/transactions=%d", size), func(b *testing.B) {
+			storage := hotAddressStorage(size)
+
+			b.ResetTimer()
+			for i := range b.N {
+				storage.Exists("addr1", fmt.Sprintf("hash%d", i%(2*size)))
+			}
+		})
+
+		b.Run(fmt.Sprintf("FindByAddressPage/transactions=%d", size), func(b *testing.B) {
+			storage := hotAddressStorage(size)
+			after := data.TransactionPosition{BlockNumber: size - 100}
+
+			b.ResetTimer()
+			for range b.N {
+				storage.FindByAddress("addr1", data.TransactionFilter{ToBlock: size, After: &after, Limit: 50})
+			}
+		})
+
+		b.Run(fmt.Sprintf("FetchAllByAddress/transactions=%d", size), func(b *testing.B) {
+			storage := hotAddressStorage(size)
+			storage.FetchAllByAddress("addr1", size)
+
+			b.ResetTimer()
+			for i := range b.N {
+				storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: size + i + 1, Hash: fmt.Sprintf("new%d", i)})
+				storage.FetchAllByAddress("addr1", size+i+1)
+			}
+		})
+
+		b.Run(fmt.Sprintf("SaveAndRetractBlock/transactions=%d", size), func(b *testing.B) {
+			storage := hotAddressStorage(size)
+
+			b.ResetTimer()
+			for i := range b.N {
+				storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: size + 1, Hash: fmt.Sprintf("retracted%d", i)})
+				storage.DeleteByBlockNumber(size + 1)
+			}
+		})
+
+		b.Run(fmt.Sprintf("SaveOlder/transactions=%d", size), func(b *testing.B) {
+			storage := hotAddressStorage(size)
+
+			b.ResetTimer()
+			for i := range b.N {
+				storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: size / 2, TransactionIndex: i + 1, Hash: fmt.Sprintf("older%d", i)})
+			}
+		})
+	}
+}
+
+// hotAddressStorage returns storage where addr1 has the given number of transactions, one per block,
+// and many other addresses have a single transaction.
+func hotAddressStorage(size int) *storage.TransactionInMemory {
+	storage := storage.NewTransactionInMemory()
+	for i := 1; i <= size; i++ {
+		storage.SaveForAddress("addr1", &data.Transaction{BlockNumber: i, Hash: fmt.Sprintf("hash%d", i)})
+		storage.SaveForAddress(fmt.Sprintf("other%d", i), &data.Transaction{BlockNumber: i, Hash: fmt.Sprintf("hash%d", i)})
+	}
+
+	return storage
+}